@@ -0,0 +1,81 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"inventory_management/models"
+)
+
+const (
+	apiKeyPrefix = "inv"
+	// Last-used times are only written when older than this, so busy
+	// integrations do not cause a write per request.
+	apiKeyTouchInterval = time.Minute
+)
+
+var ErrAPIKeyInvalid = errors.New("Invalid, expired or revoked API key")
+
+// CreateAPIKey stores key and returns the secret to hand to the client,
+// in the form inv_<prefix>_<secret>.
+func CreateAPIKey(db *gorm.DB, key models.APIKey) (string, models.APIKey, error) {
+	prefix := make([]byte, 4)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", key, err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", key, err
+	}
+
+	key.ID = uuid.New().String()
+	key.Prefix = hex.EncodeToString(prefix)
+	encodedSecret := base64.RawURLEncoding.EncodeToString(secret)
+	key.KeyHash = hashAPIKeySecret(encodedSecret)
+	if err := db.Create(&key).Error; err != nil {
+		return "", key, err
+	}
+	return apiKeyPrefix + "_" + key.Prefix + "_" + encodedSecret, key, nil
+}
+
+// FindAPIKey looks raw up by its prefix and checks the secret, expiry and
+// revocation. It records the use on success.
+func FindAPIKey(db *gorm.DB, raw string) (models.APIKey, error) {
+	var key models.APIKey
+	parts := strings.SplitN(raw, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return key, ErrAPIKeyInvalid
+	}
+
+	if err := db.Where("prefix = ?", parts[1]).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return key, ErrAPIKeyInvalid
+		}
+		return key, err
+	}
+	hash := hashAPIKeySecret(parts[2])
+	now := time.Now()
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(key.KeyHash)) != 1 || !key.Active(now) {
+		return key, ErrAPIKeyInvalid
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+		key.LastUsedAt = &now
+		db.Model(&key).UpdateColumn("last_used_at", now)
+	}
+	return key, nil
+}
+
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -19,9 +19,15 @@ func InitRedis() {
 	})
 }
 
-func GetItemFromCache(id string, item *models.Item) bool {
+// itemCacheKey namespaces cached items by tenant, so an ID leaked from
+// one tenant cannot be read through another.
+func itemCacheKey(tenant, id string) string {
+	return "tenant:" + tenant + ":item:" + id
+}
+
+func GetItemFromCache(tenant, id string, item *models.Item) bool {
 	if RedisClient != nil && RedisCtx != nil {
-		cached, err := RedisClient.Get(RedisCtx, "item:"+id).Result()
+		cached, err := RedisClient.Get(RedisCtx, itemCacheKey(tenant, id)).Result()
 		if err == nil {
 			if err := json.Unmarshal([]byte(cached), item); err == nil {
 				return true
@@ -31,15 +37,36 @@ func GetItemFromCache(id string, item *models.Item) bool {
 	return false
 }
 
-func SetItemToCache(id string, item models.Item) {
+func SetItemToCache(tenant, id string, item models.Item) {
 	if RedisClient != nil && RedisCtx != nil {
 		b, _ := json.Marshal(item)
-		_ = RedisClient.Set(RedisCtx, "item:"+id, b, 0).Err()
+		_ = RedisClient.Set(RedisCtx, itemCacheKey(tenant, id), b, 0).Err()
 	}
 }
 
-func DeleteItemFromCache(id string) {
+func DeleteItemFromCache(tenant, id string) {
 	if RedisClient != nil && RedisCtx != nil {
-		_ = RedisClient.Del(RedisCtx, "item:"+id).Err()
+		_ = RedisClient.Del(RedisCtx, itemCacheKey(tenant, id)).Err()
+	}
+}
+
+func SetItemsToCache(tenant string, items []models.Item) {
+	if RedisClient != nil && RedisCtx != nil && len(items) > 0 {
+		pipe := RedisClient.Pipeline()
+		for _, item := range items {
+			b, _ := json.Marshal(item)
+			pipe.Set(RedisCtx, itemCacheKey(tenant, item.ID), b, 0)
+		}
+		_, _ = pipe.Exec(RedisCtx)
+	}
+}
+
+func DeleteItemsFromCache(tenant string, ids []string) {
+	if RedisClient != nil && RedisCtx != nil && len(ids) > 0 {
+		keys := make([]string, len(ids))
+		for i, id := range ids {
+			keys[i] = itemCacheKey(tenant, id)
+		}
+		_ = RedisClient.Del(RedisCtx, keys...).Err()
 	}
 }
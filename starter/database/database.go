@@ -53,6 +53,9 @@ func InitDatabase() {
 	if err != nil {
 		log.Fatal("Failed to connect to the database!", err)
 	}
+	if err := DB.Use(TenantPlugin{}); err != nil {
+		log.Fatal("Failed to register the tenant plugin!", err)
+	}
 
 	sqlDB, err := DB.DB()
 	if err != nil {
@@ -62,10 +65,37 @@ func InitDatabase() {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	DB.AutoMigrate(&models.Item{})
+	if err := Migrate(DB); err != nil {
+		log.Fatal("Failed to migrate the database!", err)
+	}
+	if err := SeedAdminUser(DB); err != nil {
+		log.Fatal("Failed to create the admin user!", err)
+	}
 	seedDatabase()
 }
 
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&models.Item{}, &models.Tag{}, &models.AuditLog{}, &models.User{}, &models.RefreshToken{}, &models.APIKey{}, &models.RecoveryCode{}); err != nil {
+		return err
+	}
+	// SKUs and tag names used to be unique across the whole table; they
+	// are now unique per tenant.
+	for _, legacy := range []struct {
+		model interface{}
+		index string
+	}{{&models.Item{}, "idx_items_sku"}, {&models.Tag{}, "idx_tags_name"}} {
+		if db.Migrator().HasIndex(legacy.model, legacy.index) {
+			if err := db.Migrator().DropIndex(legacy.model, legacy.index); err != nil {
+				return err
+			}
+		}
+	}
+	if SupportsFullTextSearch(db) {
+		return migrateSearchIndexes(db)
+	}
+	return nil
+}
+
 func monitorPgxPool(pool *pgxpool.Pool) {
 	for {
 		stats := pool.Stat()
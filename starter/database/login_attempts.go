@@ -0,0 +1,111 @@
+package database
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultLoginLockout       = 30 * time.Second
+	defaultLoginLockoutMax    = 15 * time.Minute
+	defaultLoginFailureWindow = 15 * time.Minute
+)
+
+// loginAttempts counts the failed logins of one key (a username or a
+// client IP). It is used when Redis is unavailable; with Redis the
+// counters are shared by all instances.
+type loginAttempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+var loginFailures = struct {
+	sync.Mutex
+	byKey map[string]*loginAttempts
+}{byKey: make(map[string]*loginAttempts)}
+
+// LoginBackoff is how long a key is locked after failures failed logins:
+// LOGIN_LOCKOUT (default 30s) once threshold is reached, doubling with
+// every further failure up to LOGIN_LOCKOUT_MAX (default 15m).
+func LoginBackoff(failures, threshold int) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+	base := DurationFromEnv("LOGIN_LOCKOUT", defaultLoginLockout)
+	limit := DurationFromEnv("LOGIN_LOCKOUT_MAX", defaultLoginLockoutMax)
+	lockout := base
+	for i := threshold; i < failures && lockout < limit; i++ {
+		lockout *= 2
+	}
+	return min(lockout, limit)
+}
+
+// LoginLockout returns how much longer logins for key are locked.
+func LoginLockout(key string) time.Duration {
+	if RedisClient != nil && RedisCtx != nil {
+		ttl, err := RedisClient.PTTL(RedisCtx, "login:locked:"+key).Result()
+		if err == nil {
+			return max(ttl, 0)
+		}
+	}
+
+	loginFailures.Lock()
+	defer loginFailures.Unlock()
+	if attempts, ok := loginFailures.byKey[key]; ok {
+		return max(time.Until(attempts.lockedUntil), 0)
+	}
+	return 0
+}
+
+// RecordLoginFailure counts a failed login for key. Failures are forgotten
+// after LOGIN_FAILURE_WINDOW (default 15m) without another one. It returns
+// the failure count and, when this failure locked the key, for how long.
+func RecordLoginFailure(key string, threshold int) (int, time.Duration) {
+	window := DurationFromEnv("LOGIN_FAILURE_WINDOW", defaultLoginFailureWindow)
+
+	if RedisClient != nil && RedisCtx != nil {
+		failures, err := RedisClient.Incr(RedisCtx, "login:failures:"+key).Result()
+		if err == nil {
+			RedisClient.Expire(RedisCtx, "login:failures:"+key, window)
+			lockout := LoginBackoff(int(failures), threshold)
+			if lockout > 0 {
+				RedisClient.Set(RedisCtx, "login:locked:"+key, 1, lockout)
+			}
+			return int(failures), lockout
+		}
+	}
+
+	loginFailures.Lock()
+	defer loginFailures.Unlock()
+	now := time.Now()
+	for k, attempts := range loginFailures.byKey {
+		if now.Sub(attempts.lastFailure) > window && now.After(attempts.lockedUntil) {
+			delete(loginFailures.byKey, k)
+		}
+	}
+
+	attempts, ok := loginFailures.byKey[key]
+	if !ok {
+		attempts = &loginAttempts{}
+		loginFailures.byKey[key] = attempts
+	}
+	attempts.failures++
+	attempts.lastFailure = now
+	lockout := LoginBackoff(attempts.failures, threshold)
+	if lockout > 0 {
+		attempts.lockedUntil = now.Add(lockout)
+	}
+	return attempts.failures, lockout
+}
+
+// ResetLoginFailures clears the counter of key after a successful login.
+func ResetLoginFailures(key string) {
+	if RedisClient != nil && RedisCtx != nil {
+		RedisClient.Del(RedisCtx, "login:failures:"+key, "login:locked:"+key)
+	}
+
+	loginFailures.Lock()
+	delete(loginFailures.byKey, key)
+	loginFailures.Unlock()
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"inventory_management/models"
+)
+
+const recoveryCodeCount = 10
+
+var ErrSecondFactorInvalid = errors.New("Invalid verification code")
+
+// ConsumeTOTPStep records step as the time step of the last TOTP code the
+// user logged in with. A code of that or an earlier step was already used
+// and fails with ErrSecondFactorInvalid.
+func ConsumeTOTPStep(db *gorm.DB, userID string, step int64) error {
+	result := db.Model(&models.User{}).
+		Where("id = ? AND mfa_last_step < ?", userID, step).
+		Update("mfa_last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSecondFactorInvalid
+	}
+	return nil
+}
+
+// CreateRecoveryCodes replaces the recovery codes of userID with new ones.
+// The codes are only returned here; the database keeps their hashes.
+func CreateRecoveryCodes(db *gorm.DB, userID string) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 10)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(raw))
+		codes[i] = code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16]
+		records[i] = models.RecoveryCode{ID: uuid.New().String(), UserID: userID, CodeHash: hashRecoveryCode(codes[i])}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&records).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// UseRecoveryCode spends one of the recovery codes of userID. Unknown and
+// already used codes fail with ErrSecondFactorInvalid.
+func UseRecoveryCode(db *gorm.DB, userID, code string) error {
+	result := db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSecondFactorInvalid
+	}
+	return nil
+}
+
+// DisableMFA removes the second factor of userID together with its
+// recovery codes.
+func DisableMFA(db *gorm.DB, userID string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{"mfa_enabled": false, "mfa_secret": "", "mfa_last_step": 0}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+// hashRecoveryCode ignores case, spaces and dashes, which people tend to
+// get wrong when typing a code.
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"inventory_management/models"
+)
+
+const (
+	defaultRetention     = 30 * 24 * time.Hour
+	defaultPurgeInterval = time.Hour
+	purgeBatchSize       = 500
+)
+
+func StartPurgeJob() {
+	retention := DurationFromEnv("SOFT_DELETE_RETENTION", defaultRetention)
+	interval := DurationFromEnv("PURGE_INTERVAL", defaultPurgeInterval)
+
+	go func() {
+		for {
+			purged, err := PurgeDeletedItems(time.Now().Add(-retention))
+			if err != nil {
+				log.Println("[Purge] Failed to purge deleted items:", err)
+			} else if purged > 0 {
+				log.Printf("[Purge] Permanently removed %d items deleted more than %s ago", purged, retention)
+			}
+			if expired, err := PurgeExpiredRefreshTokens(time.Now()); err != nil {
+				log.Println("[Purge] Failed to purge expired refresh tokens:", err)
+			} else if expired > 0 {
+				log.Printf("[Purge] Removed %d expired refresh tokens", expired)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+func PurgeDeletedItems(deletedBefore time.Time) (int64, error) {
+	var purged int64
+	for {
+		var expired []models.Item
+		err := DB.Unscoped().Select("id", "tenant_id").
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).
+			Limit(purgeBatchSize).
+			Find(&expired).Error
+		if err != nil {
+			return purged, err
+		}
+		if len(expired) == 0 {
+			return purged, nil
+		}
+		ids := make([]string, len(expired))
+		for i, item := range expired {
+			ids[i] = item.ID
+		}
+
+		if err := DB.Exec("DELETE FROM item_tags WHERE item_id IN ?", ids).Error; err != nil {
+			return purged, err
+		}
+		result := DB.Unscoped().Where("id IN ?", ids).Delete(&models.Item{})
+		if result.Error != nil {
+			return purged, result.Error
+		}
+		purged += result.RowsAffected
+
+		for _, item := range expired {
+			DeleteItemFromCache(item.TenantID, item.ID)
+		}
+	}
+}
+
+// DurationFromEnv reads a time.ParseDuration value, falling back to def
+// when it is unset or invalid.
+func DurationFromEnv(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("Warning: invalid %s %q, using %s", key, v, def)
+		return def
+	}
+	return d
+}
+
+// IntFromEnv reads a positive integer, falling back to def when it is
+// unset or invalid.
+func IntFromEnv(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		log.Printf("Warning: invalid %s %q, using %d", key, v, def)
+		return def
+	}
+	return n
+}
@@ -0,0 +1,23 @@
+package database
+
+import "gorm.io/gorm"
+
+// SupportsFullTextSearch reports whether the database can serve item search
+// itself; other databases fall back to matching in process.
+func SupportsFullTextSearch(db *gorm.DB) bool {
+	return db.Dialector.Name() == "postgres"
+}
+
+func migrateSearchIndexes(db *gorm.DB) error {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`CREATE INDEX IF NOT EXISTS idx_items_name_fts ON items USING GIN (to_tsvector('simple', name))`,
+		`CREATE INDEX IF NOT EXISTS idx_items_name_trgm ON items USING GIN (name gin_trgm_ops)`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"regexp"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultTenant owns the data of single-tenant deployments and of rows
+// created before tenants existed.
+const DefaultTenant = "default"
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+func ValidTenantID(tenant string) bool {
+	return tenantIDPattern.MatchString(tenant)
+}
+
+type tenantContextKey struct{}
+
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// ForTenant returns DB limited to the rows of tenant.
+func ForTenant(tenant string) *gorm.DB {
+	return DB.WithContext(WithTenant(context.Background(), tenant))
+}
+
+// TenantPlugin isolates tenants. Every model with a TenantID field is
+// tenant-owned: statements whose context carries a tenant (see WithTenant)
+// only read, update and delete that tenant's rows, and the records they
+// create are assigned to it whatever TenantID they were given. Statements
+// without a tenant, such as logins and background jobs, see every tenant.
+type TenantPlugin struct{}
+
+func (TenantPlugin) Name() string {
+	return "tenant"
+}
+
+func (TenantPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("tenant:assign", assignTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	return callbacks.Delete().Before("gorm:delete").Register("tenant:scope", scopeTenant)
+}
+
+func statementTenant(db *gorm.DB) (string, bool) {
+	tenant, ok := TenantFromContext(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil || db.Statement.Schema.LookUpField("TenantID") == nil {
+		return "", false
+	}
+	return tenant, true
+}
+
+func assignTenant(db *gorm.DB) {
+	if tenant, ok := statementTenant(db); ok {
+		db.Statement.SetColumn("TenantID", tenant, true)
+	}
+}
+
+func scopeTenant(db *gorm.DB) {
+	if tenant, ok := statementTenant(db); ok {
+		db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+			clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "tenant_id"}, Value: tenant},
+		}})
+	}
+}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"inventory_management/models"
+)
+
+const defaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	ErrRefreshTokenInvalid = errors.New("Invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("Refresh token has already been used; all sessions from it were revoked")
+)
+
+// CreateRefreshToken issues a new refresh token for userID. An empty
+// familyID starts a new family, i.e. a new login session; mfa records
+// whether that login passed a second factor.
+func CreateRefreshToken(db *gorm.DB, userID, familyID, scope string, mfa bool) (string, models.RefreshToken, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", models.RefreshToken{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	if familyID == "" {
+		familyID = uuid.New().String()
+	}
+	record := models.RefreshToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		Scope:     scope,
+		MFA:       mfa,
+		TokenHash: hashRefreshToken(token),
+		ExpiresAt: time.Now().Add(DurationFromEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)),
+	}
+	if err := db.Create(&record).Error; err != nil {
+		return "", record, err
+	}
+	return token, record, nil
+}
+
+// RotateRefreshToken exchanges token for a new one in the same family.
+// Presenting a token that was already rotated means it leaked, so the
+// whole family is revoked and ErrRefreshTokenReused returned.
+func RotateRefreshToken(db *gorm.DB, token string) (string, models.RefreshToken, error) {
+	var current models.RefreshToken
+	if err := db.Where("token_hash = ?", hashRefreshToken(token)).First(&current).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", current, ErrRefreshTokenInvalid
+		}
+		return "", current, err
+	}
+
+	if current.RevokedAt != nil {
+		if current.ReplacedBy == "" {
+			return "", current, ErrRefreshTokenInvalid
+		}
+		if err := RevokeRefreshTokenFamily(db, current.FamilyID); err != nil {
+			return "", current, err
+		}
+		return "", current, ErrRefreshTokenReused
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return "", current, ErrRefreshTokenInvalid
+	}
+
+	var next string
+	var record models.RefreshToken
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		next, record, err = CreateRefreshToken(tx, current.UserID, current.FamilyID, current.Scope, current.MFA)
+		if err != nil {
+			return err
+		}
+		// Two concurrent refreshes with the same token must not both
+		// succeed; the loser is treated as a reuse.
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", current.ID).
+			Updates(map[string]interface{}{"revoked_at": time.Now(), "replaced_by": record.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+		return nil
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		if err := RevokeRefreshTokenFamily(db, current.FamilyID); err != nil {
+			return "", current, err
+		}
+		return "", current, ErrRefreshTokenReused
+	}
+	return next, record, err
+}
+
+// FindRefreshToken returns the stored record for token, revoked or not.
+func FindRefreshToken(db *gorm.DB, token string) (models.RefreshToken, error) {
+	var record models.RefreshToken
+	err := db.Where("token_hash = ?", hashRefreshToken(token)).First(&record).Error
+	return record, err
+}
+
+func RevokeRefreshTokenFamily(db *gorm.DB, familyID string) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUserRefreshTokens ends every session of a user, e.g. after a
+// password change.
+func RevokeUserRefreshTokens(db *gorm.DB, userID string) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// PurgeExpiredRefreshTokens deletes tokens that can no longer be used.
+// Rotated tokens are kept until they expire so reuse is still detected.
+func PurgeExpiredRefreshTokens(before time.Time) (int64, error) {
+	result := DB.Where("expires_at < ?", before).Delete(&models.RefreshToken{})
+	return result.RowsAffected, result.Error
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// revokedTokens is the in-memory revocation list. It is always written so
+// revocations survive a Redis outage on this instance; Redis shares them
+// with the others.
+var revokedTokens = struct {
+	sync.Mutex
+	expiry map[string]time.Time
+}{expiry: make(map[string]time.Time)}
+
+// RevokeToken adds an access token's jti to the revocation list until the
+// token would have expired anyway.
+func RevokeToken(jti string, expiresAt time.Time) {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return
+	}
+
+	revokedTokens.Lock()
+	now := time.Now()
+	for id, expiry := range revokedTokens.expiry {
+		if now.After(expiry) {
+			delete(revokedTokens.expiry, id)
+		}
+	}
+	revokedTokens.expiry[jti] = expiresAt
+	revokedTokens.Unlock()
+
+	if RedisClient != nil && RedisCtx != nil {
+		_ = RedisClient.Set(RedisCtx, "revoked:jti:"+jti, 1, ttl).Err()
+	}
+}
+
+func IsTokenRevoked(jti string) bool {
+	revokedTokens.Lock()
+	expiry, ok := revokedTokens.expiry[jti]
+	revokedTokens.Unlock()
+	if ok && time.Now().Before(expiry) {
+		return true
+	}
+
+	if RedisClient != nil && RedisCtx != nil {
+		n, err := RedisClient.Exists(RedisCtx, "revoked:jti:"+jti).Result()
+		return err == nil && n > 0
+	}
+	return false
+}
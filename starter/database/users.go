@@ -0,0 +1,82 @@
+package database
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"inventory_management/models"
+)
+
+// SeedAdminUser creates the first account from ADMIN_USERNAME and
+// ADMIN_PASSWORD when the users table is empty. Tables created before
+// roles existed have no admin, so ADMIN_USERNAME is promoted instead.
+func SeedAdminUser(db *gorm.DB) error {
+	username := os.Getenv("ADMIN_USERNAME")
+	if username == "" {
+		username = "admin"
+	}
+
+	var count int64
+	if err := db.Model(&models.User{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		var admins int64
+		if err := db.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&admins).Error; err != nil {
+			return err
+		}
+		if admins > 0 {
+			return nil
+		}
+		return db.Model(&models.User{}).Where("username = ?", username).Update("role", models.RoleAdmin).Error
+	}
+
+	password := os.Getenv("ADMIN_PASSWORD")
+	if password == "" {
+		password = "password"
+		log.Println("[Users] ADMIN_PASSWORD is not set; created the admin user with the default password, change it immediately")
+	}
+
+	user := models.User{ID: uuid.New().String(), Username: username, Role: models.RoleAdmin}
+	if err := user.SetPassword(password); err != nil {
+		return err
+	}
+	return db.Create(&user).Error
+}
+
+var ErrUsernameTaken = errors.New("Username is already taken by another account")
+
+// UpsertExternalUser finds or creates the local account linked to an
+// identity provider subject and keeps its role and tenant in sync with the
+// provider.
+func UpsertExternalUser(db *gorm.DB, externalID, username string, role models.Role, tenant string) (models.User, error) {
+	var user models.User
+	err := db.Where("external_id = ?", externalID).First(&user).Error
+	if err == nil {
+		if user.Role != role || user.TenantID != tenant {
+			if err := db.Model(&user).Updates(map[string]interface{}{"role": role, "tenant_id": tenant}).Error; err != nil {
+				return user, err
+			}
+			user.Role, user.TenantID = role, tenant
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+
+	var count int64
+	if err := db.Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		return user, err
+	}
+	if count > 0 {
+		return user, ErrUsernameTaken
+	}
+
+	user = models.User{ID: uuid.New().String(), TenantID: tenant, Username: username, ExternalID: &externalID, Role: role}
+	return user, db.Create(&user).Error
+}
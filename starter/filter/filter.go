@@ -0,0 +1,361 @@
+// Package filter parses filter expressions such as
+//
+//	price gte 100 and stock lt 5 or name contains 'usb'
+//
+// into an AST and translates it to a parameterized SQL condition. Only the
+// fields passed to Parse can be referenced; "and" binds tighter than "or".
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MaxLength   = 1000
+	maxDepth    = 20
+	maxInValues = 100
+)
+
+type FieldType int
+
+const (
+	String FieldType = iota
+	Int
+	Float
+	Time
+)
+
+// Field describes a filterable column. Enum, when set, lists the only
+// values a string field accepts.
+type Field struct {
+	Column string
+	Type   FieldType
+	Enum   []string
+}
+
+// Error points at the token that made the expression invalid.
+type Error struct {
+	Pos     int
+	Token   string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("Invalid filter at position %d: %s", e.Pos, e.Message)
+	}
+	return fmt.Sprintf("Invalid filter at position %d near '%s': %s", e.Pos, e.Token, e.Message)
+}
+
+type Node interface {
+	sql(b *strings.Builder, args *[]interface{})
+}
+
+type Logical struct {
+	Op    string
+	Left  Node
+	Right Node
+}
+
+type Not struct {
+	Expr Node
+}
+
+type Comparison struct {
+	Field  Field
+	Op     string
+	Values []interface{}
+}
+
+var comparisonOps = map[string]bool{
+	"eq": true, "ne": true, "gt": true, "gte": true, "lt": true, "lte": true,
+	"contains": true, "startswith": true, "endswith": true, "in": true,
+}
+
+var textOps = map[string]bool{"contains": true, "startswith": true, "endswith": true}
+
+type parser struct {
+	tokens []token
+	pos    int
+	fields map[string]Field
+	depth  int
+}
+
+func Parse(input string, fields map[string]Field) (Node, error) {
+	if len(input) > MaxLength {
+		return nil, &Error{Pos: 1, Message: fmt.Sprintf("filter may be at most %d characters", MaxLength)}
+	}
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, fields: fields}
+	if p.peek().kind == tokenEOF {
+		return nil, &Error{Pos: 1, Message: "filter is empty"}
+	}
+
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorAt(tok, "expected 'and', 'or' or end of filter")
+	}
+	return node, nil
+}
+
+// SQL returns the condition for node with its placeholder arguments.
+func SQL(node Node) (string, []interface{}) {
+	var b strings.Builder
+	var args []interface{}
+	node.sql(&b, &args)
+	return b.String(), args
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) errorAt(tok token, message string) *Error {
+	text := tok.text
+	if tok.kind == tokenEOF {
+		text = ""
+		message = strings.Replace(message, "expected", "unexpected end of filter, expected", 1)
+	}
+	return &Error{Pos: tok.pos, Token: text, Message: message}
+}
+
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: "OR", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: "AND", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, p.errorAt(p.peek(), "filter is nested too deeply")
+	}
+
+	tok := p.peek()
+	if tok.is("not") {
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: expr}, nil
+	}
+
+	if tok.kind == tokenLParen {
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, p.errorAt(closing, "expected ')'")
+		}
+		return expr, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	fieldTok := p.next()
+	if fieldTok.kind != tokenIdent {
+		return nil, p.errorAt(fieldTok, "expected a field name")
+	}
+	field, ok := p.fields[strings.ToLower(fieldTok.text)]
+	if !ok {
+		return nil, p.errorAt(fieldTok, "unknown field")
+	}
+
+	opTok := p.next()
+	op := strings.ToLower(opTok.text)
+	if opTok.kind != tokenIdent || !comparisonOps[op] {
+		return nil, p.errorAt(opTok, "expected an operator (eq, ne, gt, gte, lt, lte, contains, startswith, endswith, in)")
+	}
+	if textOps[op] && field.Type != String {
+		return nil, p.errorAt(opTok, fmt.Sprintf("'%s' only works on text fields", op))
+	}
+
+	comparison := &Comparison{Field: field, Op: op}
+
+	if op != "in" {
+		value, err := p.parseValue(field, textOps[op])
+		if err != nil {
+			return nil, err
+		}
+		comparison.Values = []interface{}{value}
+		return comparison, nil
+	}
+
+	if open := p.next(); open.kind != tokenLParen {
+		return nil, p.errorAt(open, "expected '(' after 'in'")
+	}
+	for {
+		value, err := p.parseValue(field, false)
+		if err != nil {
+			return nil, err
+		}
+		comparison.Values = append(comparison.Values, value)
+		if len(comparison.Values) > maxInValues {
+			return nil, p.errorAt(p.peek(), fmt.Sprintf("'in' accepts at most %d values", maxInValues))
+		}
+
+		sep := p.next()
+		if sep.kind == tokenRParen {
+			return comparison, nil
+		}
+		if sep.kind != tokenComma {
+			return nil, p.errorAt(sep, "expected ',' or ')'")
+		}
+	}
+}
+
+// parseValue converts the next literal to the field's type. Text operators
+// accept any string, so enum checks do not apply to them.
+func (p *parser) parseValue(field Field, textOp bool) (interface{}, error) {
+	tok := p.next()
+	switch field.Type {
+	case String:
+		if tok.kind != tokenString {
+			return nil, p.errorAt(tok, "expected a quoted string")
+		}
+		if !textOp && len(field.Enum) > 0 && !contains(field.Enum, tok.value) {
+			return nil, p.errorAt(tok, "expected one of "+strings.Join(field.Enum, ", "))
+		}
+		return tok.value, nil
+	case Int:
+		if tok.kind != tokenNumber {
+			return nil, p.errorAt(tok, "expected a whole number")
+		}
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, p.errorAt(tok, "expected a whole number")
+		}
+		return n, nil
+	case Float:
+		if tok.kind != tokenNumber {
+			return nil, p.errorAt(tok, "expected a number")
+		}
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, p.errorAt(tok, "expected a number")
+		}
+		return f, nil
+	case Time:
+		if tok.kind != tokenString {
+			return nil, p.errorAt(tok, "expected a quoted RFC3339 timestamp")
+		}
+		t, err := time.Parse(time.RFC3339, tok.value)
+		if err != nil {
+			return nil, p.errorAt(tok, "expected a quoted RFC3339 timestamp")
+		}
+		return t, nil
+	}
+	return nil, p.errorAt(tok, "unsupported field type")
+}
+
+func (n *Logical) sql(b *strings.Builder, args *[]interface{}) {
+	b.WriteString("(")
+	n.Left.sql(b, args)
+	b.WriteString(" " + n.Op + " ")
+	n.Right.sql(b, args)
+	b.WriteString(")")
+}
+
+func (n *Not) sql(b *strings.Builder, args *[]interface{}) {
+	b.WriteString("NOT (")
+	n.Expr.sql(b, args)
+	b.WriteString(")")
+}
+
+func (n *Comparison) sql(b *strings.Builder, args *[]interface{}) {
+	column := n.Field.Column
+	switch n.Op {
+	case "eq":
+		b.WriteString(column + " = ?")
+	case "ne":
+		b.WriteString(column + " <> ?")
+	case "gt":
+		b.WriteString(column + " > ?")
+	case "gte":
+		b.WriteString(column + " >= ?")
+	case "lt":
+		b.WriteString(column + " < ?")
+	case "lte":
+		b.WriteString(column + " <= ?")
+	case "in":
+		b.WriteString(column + " IN ?")
+		*args = append(*args, n.Values)
+		return
+	default:
+		pattern := escapeLike(strings.ToLower(n.Values[0].(string)))
+		switch n.Op {
+		case "contains":
+			pattern = "%" + pattern + "%"
+		case "startswith":
+			pattern = pattern + "%"
+		case "endswith":
+			pattern = "%" + pattern
+		}
+		b.WriteString("LOWER(" + column + ") LIKE ? ESCAPE '\\'")
+		*args = append(*args, pattern)
+		return
+	}
+	*args = append(*args, n.Values[0])
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
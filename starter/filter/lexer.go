@@ -0,0 +1,104 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value string
+	pos   int
+}
+
+func (t token) is(keyword string) bool {
+	return t.kind == tokenIdent && strings.EqualFold(t.text, keyword)
+}
+
+// lex splits the input into tokens; positions are 1-based rune offsets so
+// they can be shown to API clients as-is.
+func lex(input string) ([]token, error) {
+	runes := []rune(input)
+	var tokens []token
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: pos})
+			i++
+
+		case r == '\'':
+			var value strings.Builder
+			j := i + 1
+			for {
+				if j >= len(runes) {
+					return nil, &Error{Pos: pos, Token: string(runes[i:]), Message: "unterminated string"}
+				}
+				if runes[j] == '\'' {
+					if j+1 < len(runes) && runes[j+1] == '\'' {
+						value.WriteRune('\'')
+						j += 2
+						continue
+					}
+					break
+				}
+				value.WriteRune(runes[j])
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenString, text: string(runes[i : j+1]), value: value.String(), pos: pos})
+			i = j + 1
+
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i + 1
+			seenDot := false
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || (runes[j] == '.' && !seenDot)) {
+				if runes[j] == '.' {
+					seenDot = true
+				}
+				j++
+			}
+			text := string(runes[i:j])
+			tokens = append(tokens, token{kind: tokenNumber, text: text, value: text, pos: pos})
+			i = j
+
+		case unicode.IsLetter(r) || r == '_':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			text := string(runes[i:j])
+			tokens = append(tokens, token{kind: tokenIdent, text: text, value: text, pos: pos})
+			i = j
+
+		default:
+			return nil, &Error{Pos: pos, Token: string(r), Message: "unexpected character"}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes) + 1}), nil
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxHistogramEdges = 20
+
+var (
+	defaultPriceBuckets = []float64{0, 10, 50, 100, 500, 1000}
+	defaultStockBuckets = []float64{0, 1, 10, 50, 100}
+)
+
+type HistogramBucket struct {
+	From  *float64 `json:"from"`
+	To    *float64 `json:"to"`
+	Count int64    `json:"count"`
+}
+
+type ItemAggregations struct {
+	Count          int64             `json:"count"`
+	TotalStock     int64             `json:"total_stock"`
+	InventoryValue float64           `json:"inventory_value"`
+	MinPrice       *float64          `json:"min_price"`
+	MaxPrice       *float64          `json:"max_price"`
+	AvgPrice       *float64          `json:"avg_price"`
+	PriceHistogram []HistogramBucket `json:"price_histogram"`
+	StockHistogram []HistogramBucket `json:"stock_histogram"`
+}
+
+// itemListAggregations computes the aggregations for the filtered list when
+// `aggregations=true`; it returns nil when they were not requested and the
+// HTTP status to respond with on failure.
+func itemListAggregations(c *gin.Context, query *gorm.DB) (*ItemAggregations, int, error) {
+	if c.Query("aggregations") != "true" {
+		return nil, http.StatusOK, nil
+	}
+
+	priceEdges, err := parseHistogramEdges(c.Query("price_buckets"), defaultPriceBuckets, "price_buckets")
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	stockEdges, err := parseHistogramEdges(c.Query("stock_buckets"), defaultStockBuckets, "stock_buckets")
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	var aggregations ItemAggregations
+	var minPrice, maxPrice, avgPrice sql.NullFloat64
+	err = query.Session(&gorm.Session{}).
+		Select("COUNT(*), "+
+			"CAST(COALESCE(SUM(stock), 0) AS BIGINT), "+
+			"COALESCE(SUM(stock * price), 0), "+
+			"MIN(price), MAX(price), AVG(price)").
+		Row().
+		Scan(&aggregations.Count, &aggregations.TotalStock, &aggregations.InventoryValue, &minPrice, &maxPrice, &avgPrice)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Failed to compute aggregations")
+	}
+	aggregations.InventoryValue = math.Round(aggregations.InventoryValue*100) / 100
+	aggregations.MinPrice = nullFloat(minPrice)
+	aggregations.MaxPrice = nullFloat(maxPrice)
+	aggregations.AvgPrice = nullFloat(avgPrice)
+	if aggregations.AvgPrice != nil {
+		*aggregations.AvgPrice = math.Round(*aggregations.AvgPrice*100) / 100
+	}
+
+	if aggregations.PriceHistogram, err = histogram(query, "price", priceEdges); err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Failed to compute aggregations")
+	}
+	if aggregations.StockHistogram, err = histogram(query, "stock", stockEdges); err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Failed to compute aggregations")
+	}
+	return &aggregations, http.StatusOK, nil
+}
+
+// histogram counts rows per bucket in a single query. Buckets are
+// [edge, next edge), plus an open bucket below the first edge and one
+// from the last edge upwards.
+func histogram(query *gorm.DB, column string, edges []float64) ([]HistogramBucket, error) {
+	buckets := make([]HistogramBucket, 0, len(edges)+1)
+	buckets = append(buckets, HistogramBucket{To: &edges[0]})
+	for i := range edges {
+		bucket := HistogramBucket{From: &edges[i]}
+		if i+1 < len(edges) {
+			bucket.To = &edges[i+1]
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	columns := make([]string, len(buckets))
+	var args []interface{}
+	for i, bucket := range buckets {
+		var conditions []string
+		if bucket.From != nil {
+			conditions = append(conditions, column+" >= ?")
+			args = append(args, *bucket.From)
+		}
+		if bucket.To != nil {
+			conditions = append(conditions, column+" < ?")
+			args = append(args, *bucket.To)
+		}
+		columns[i] = "COUNT(CASE WHEN " + strings.Join(conditions, " AND ") + " THEN 1 END)"
+	}
+
+	dest := make([]interface{}, len(buckets))
+	for i := range buckets {
+		dest[i] = &buckets[i].Count
+	}
+	err := query.Session(&gorm.Session{}).Select(strings.Join(columns, ", "), args...).Row().Scan(dest...)
+	return buckets, err
+}
+
+func parseHistogramEdges(param string, defaults []float64, name string) ([]float64, error) {
+	if param == "" {
+		return defaults, nil
+	}
+
+	parts := strings.Split(param, ",")
+	if len(parts) > maxHistogramEdges {
+		return nil, fmt.Errorf("%s may have at most %d edges", name, maxHistogramEdges)
+	}
+	edges := make([]float64, len(parts))
+	for i, part := range parts {
+		edge, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(edge) || math.IsInf(edge, 0) {
+			return nil, fmt.Errorf("%s must be a comma-separated list of numbers", name)
+		}
+		if i > 0 && edge <= edges[i-1] {
+			return nil, fmt.Errorf("%s must be in increasing order", name)
+		}
+		edges[i] = edge
+	}
+	return edges, nil
+}
+
+func nullFloat(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Float64
+}
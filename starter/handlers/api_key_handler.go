@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"inventory_management/database"
+	"inventory_management/middleware"
+	"inventory_management/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,min=1,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func GetAPIKeys(c *gin.Context) {
+	var keys []models.APIKey
+	if err := middleware.TenantDB(c).Order("created_at desc").Find(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": keys})
+}
+
+// CreateAPIKey returns the key in the response only; it cannot be
+// retrieved again.
+func CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, scope := range req.Scopes {
+		if !middleware.IsAPIKeyScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scope: " + scope, "allowed_scopes": middleware.APIKeyScopes})
+			return
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+
+	raw, key, err := database.CreateAPIKey(middleware.TenantDB(c), models.APIKey{
+		Name:      req.Name,
+		Scopes:    uniqueStrings(req.Scopes),
+		CreatedBy: middleware.CurrentUsername(c),
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	middleware.RecordAudit(c, "create", "api_key", key.ID, nil, key)
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "API key created; store it now, it will not be shown again",
+		"key":     raw,
+		"data":    key,
+	})
+}
+
+func RevokeAPIKey(c *gin.Context) {
+	var key models.APIKey
+	if err := middleware.TenantDB(c).First(&key, "id = ?", c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+	if key.RevokedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "API key is already revoked"})
+		return
+	}
+
+	before := key
+	now := time.Now()
+	key.RevokedAt = &now
+	if err := middleware.TenantDB(c).Model(&key).Update("revoked_at", now).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	middleware.RecordAudit(c, "revoke", "api_key", key.ID, before, key)
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked", "data": key})
+}
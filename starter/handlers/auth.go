@@ -1,16 +1,24 @@
 package handlers
 
 import (
+	"inventory_management/database"
 	"inventory_management/middleware"
+	"inventory_management/models"
+	"math"
 	"net/http"
-	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type LoginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	// Scope optionally narrows the session, e.g. "inventory:read".
+	Scope string `json:"scope"`
 }
 
 func Login(c *gin.Context) {
@@ -19,25 +27,291 @@ func Login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
+	scopes, err := middleware.ParseScope(req.Scope)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	middleware.SetAuditActor(c, req.Username)
+	middleware.RecordAudit(c, "login", "session", "", nil, nil)
+
+	throttles := loginThrottles(c, req.Username)
+	if retryAfter := loginLockout(throttles); retryAfter > 0 {
+		// A locked-out attempt costs the same bcrypt comparison as any
+		// other, so the response time does not tell locks apart.
+		(*models.User)(nil).CheckPassword(req.Password)
+		respondLockedOut(c, retryAfter)
+		return
+	}
+
+	var user *models.User
+	var found models.User
+	if err := database.DB.Where("username = ?", req.Username).First(&found).Error; err == nil {
+		user = &found
+	} else if err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// CheckPassword also runs for unknown users so the response time does
+	// not reveal which usernames exist.
+	if !user.CheckPassword(req.Password) {
+		recordLoginFailure(c, req.Username, throttles)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+	if user.Disabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return
+	}
+	if err := middleware.CheckScopes(user.Role, scopes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "invalid_scope"})
+		return
+	}
 
-	adminUser := getenvDefault("ADMIN_USERNAME", "admin")
-	adminPass := getenvDefault("ADMIN_PASSWORD", "password")
-	if req.Username == adminUser && req.Password == adminPass {
-		token, err := middleware.GenerateJWT(req.Username)
+	// The failure counter is only reset once the second factor is passed
+	// too, so it also limits guessing TOTP codes.
+	if user.MFAEnabled {
+		challenge, err := middleware.GenerateMFAChallenge(user.Username, scopes)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"token": token})
-	} else {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required": true,
+			"mfa_token":    challenge,
+			"expires_in":   int(middleware.MFAChallengeTTL().Seconds()),
+		})
+		return
+	}
+	database.ResetLoginFailures(throttles[0].key)
+
+	respondWithTokens(c, *user, scopes, false)
+}
+
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code"`
+	// RecoveryCode replaces Code when the authenticator is not at hand.
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// LoginMFA completes the login of a user with two-factor authentication:
+// it exchanges the mfa_token from Login and a TOTP or recovery code for
+// tokens that count as having passed a second factor.
+func LoginMFA(c *gin.Context) {
+	var req MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code or recovery_code is required"})
+		return
+	}
+	challenge, err := middleware.ParseMFAChallenge(req.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	middleware.SetAuditActor(c, challenge.Username)
+	middleware.RecordAudit(c, "login_mfa", "session", "", nil, nil)
+
+	throttles := loginThrottles(c, challenge.Username)
+	if retryAfter := loginLockout(throttles); retryAfter > 0 {
+		respondLockedOut(c, retryAfter)
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Where("username = ?", challenge.Username).First(&user).Error; err != nil || user.Disabled || !user.MFAEnabled {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Account is disabled or no longer exists"})
+		return
+	}
+	if err := verifySecondFactor(c, user, req.Code, req.RecoveryCode); err != nil {
+		if err == database.ErrSecondFactorInvalid {
+			recordLoginFailure(c, challenge.Username, throttles)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+	database.RevokeToken(challenge.ID, challenge.ExpiresAt)
+	database.ResetLoginFailures(throttles[0].key)
+
+	respondWithTokens(c, user, challenge.Scopes, true)
+}
+
+func respondLockedOut(c *gin.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many failed login attempts. Please try again later.",
+		"retry_after": seconds,
+	})
+}
+
+// loginThrottle is a failed-login counter and the number of failures at
+// which it locks.
+type loginThrottle struct {
+	key       string
+	threshold int
+}
+
+// loginThrottles are the counters a login is checked against: one for the
+// username, whether or not it exists, and a looser one for the client IP
+// so that spraying many usernames is slowed down too.
+func loginThrottles(c *gin.Context, username string) []loginThrottle {
+	return []loginThrottle{
+		{key: "user:" + strings.ToLower(username), threshold: database.IntFromEnv("LOGIN_MAX_FAILURES", 5)},
+		{key: "ip:" + c.ClientIP(), threshold: database.IntFromEnv("LOGIN_MAX_FAILURES_PER_IP", 20)},
+	}
+}
+
+func loginLockout(throttles []loginThrottle) time.Duration {
+	var lockout time.Duration
+	for _, throttle := range throttles {
+		lockout = max(lockout, database.LoginLockout(throttle.key))
+	}
+	return lockout
+}
+
+// recordLoginFailure counts a failed login and writes a lockout entry to
+// the audit log for each counter it locks.
+func recordLoginFailure(c *gin.Context, username string, throttles []loginThrottle) {
+	for _, throttle := range throttles {
+		failures, lockout := database.RecordLoginFailure(throttle.key, throttle.threshold)
+		if lockout > 0 {
+			middleware.RecordAudit(c, "lockout", "session", "", nil, gin.H{
+				"username":   username,
+				"client_ip":  c.ClientIP(),
+				"counter":    throttle.key,
+				"failures":   failures,
+				"locked_for": lockout.String(),
+			})
+		}
+	}
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RefreshToken exchanges a refresh token for a new access token and a new
+// refresh token; the old refresh token cannot be used again.
+func RefreshToken(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	refreshToken, record, err := database.RotateRefreshToken(database.DB, req.RefreshToken)
+	if err != nil {
+		switch err {
+		case database.ErrRefreshTokenReused:
+			middleware.RecordAudit(c, "refresh_reuse", "session", record.FamilyID, nil, nil)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case database.ErrRefreshTokenInvalid:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		}
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", record.UserID).Error; err != nil || user.Disabled {
+		database.RevokeRefreshTokenFamily(database.DB, record.FamilyID)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Account is disabled or no longer exists"})
+		return
+	}
+
+	scopes := strings.Fields(record.Scope)
+	token, err := accessToken(user, scopes, record.MFA)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	c.JSON(http.StatusOK, tokenResponse(token, refreshToken, user, scopes))
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Logout revokes the access token used for the request and, when given,
+// the session its refresh token belongs to. API keys and identity provider
+// tokens are not sessions and are refused.
+func Logout(c *gin.Context) {
+	if !middleware.HasSession(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only access tokens from /api/v1/login can be logged out"})
+		return
+	}
+
+	var req LogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if req.RefreshToken != "" {
+		var user models.User
+		if err := database.DB.Where("username = ?", middleware.CurrentUsername(c)).First(&user).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		record, err := database.FindRefreshToken(database.DB, req.RefreshToken)
+		if err != nil || record.UserID != user.ID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		if err := database.RevokeRefreshTokenFamily(database.DB, record.FamilyID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke refresh token"})
+			return
+		}
+	}
+
+	middleware.RevokeCurrentToken(c)
+	middleware.RecordAudit(c, "logout", "session", "", nil, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// respondWithTokens starts a new session for user, limited to scopes when
+// any are given. mfa is whether the login passed a second factor.
+func respondWithTokens(c *gin.Context, user models.User, scopes []string, mfa bool) {
+	token, err := accessToken(user, scopes, mfa)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	refreshToken, _, err := database.CreateRefreshToken(database.DB, user.ID, "", strings.Join(scopes, " "), mfa)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	c.JSON(http.StatusOK, tokenResponse(token, refreshToken, user, scopes))
+}
+
+func accessToken(user models.User, scopes []string, mfa bool) (string, error) {
+	if mfa {
+		return middleware.GenerateStepUpJWT(user.Username, user.Role, scopes...)
 	}
+	return middleware.GenerateJWT(user.Username, user.Role, scopes...)
 }
 
-func getenvDefault(key, def string) string {
-	v := os.Getenv(key)
-	if v == "" {
-		v = def
+func tokenResponse(token, refreshToken string, user models.User, scopes []string) gin.H {
+	return gin.H{
+		"token":         token,
+		"token_type":    "Bearer",
+		"expires_in":    int(middleware.AccessTokenTTL().Seconds()),
+		"refresh_token": refreshToken,
+		"role":          user.Role,
+		"scope":         strings.Join(middleware.GrantedScopes(user.Role, scopes), " "),
 	}
-	return v
 }
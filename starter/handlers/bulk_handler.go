@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"errors"
+	"inventory_management/database"
+	"inventory_management/middleware"
+	"inventory_management/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	BulkModeAtomic     = "atomic"
+	BulkModeBestEffort = "best_effort"
+	maxBulkOperations  = 1000
+)
+
+type BulkOperation struct {
+	Op      string       `json:"op"`
+	ID      string       `json:"id,omitempty"`
+	Version *int         `json:"version,omitempty"`
+	Item    *models.Item `json:"item,omitempty"`
+}
+
+type BulkRequest struct {
+	Mode       string          `json:"mode"`
+	Operations []BulkOperation `json:"operations" binding:"required,min=1"`
+}
+
+type BulkResult struct {
+	Index  int          `json:"index"`
+	Op     string       `json:"op"`
+	ID     string       `json:"id,omitempty"`
+	Status int          `json:"status"`
+	Error  string       `json:"error,omitempty"`
+	Data   *models.Item `json:"data,omitempty"`
+}
+
+type BulkResponse struct {
+	Mode      string       `json:"mode"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
+
+// bulkChange is what a successful operation needs once its transaction
+// has committed: the audit entry and the cache update.
+type bulkChange struct {
+	action string
+	before *models.Item
+	after  *models.Item
+}
+
+var errBulkAborted = errors.New("bulk operation aborted")
+
+func BulkItems(c *gin.Context) {
+	var req BulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = BulkModeAtomic
+	}
+	if req.Mode != BulkModeAtomic && req.Mode != BulkModeBestEffort {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be 'atomic' or 'best_effort'"})
+		return
+	}
+
+	if len(req.Operations) > maxBulkOperations {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "A bulk request may contain at most 1000 operations"})
+		return
+	}
+
+	results := make([]BulkResult, len(req.Operations))
+	changes := make([]*bulkChange, len(req.Operations))
+
+	if req.Mode == BulkModeAtomic {
+		failedAt := -1
+		err := middleware.TenantDB(c).Transaction(func(tx *gorm.DB) error {
+			for i, op := range req.Operations {
+				results[i], changes[i] = executeBulkOperation(tx, i, op)
+				if results[i].Error != "" {
+					failedAt = i
+					return errBulkAborted
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			for i := range results {
+				changes[i] = nil
+				if i == failedAt {
+					continue
+				}
+				results[i] = BulkResult{
+					Index:  i,
+					Op:     req.Operations[i].Op,
+					ID:     req.Operations[i].ID,
+					Status: http.StatusFailedDependency,
+					Error:  "Not applied because the bulk request was rolled back",
+				}
+			}
+			if failedAt < 0 {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply bulk operations"})
+				return
+			}
+		}
+	} else {
+		for i, op := range req.Operations {
+			middleware.TenantDB(c).Transaction(func(tx *gorm.DB) error {
+				results[i], changes[i] = executeBulkOperation(tx, i, op)
+				if results[i].Error != "" {
+					return errBulkAborted
+				}
+				return nil
+			})
+		}
+	}
+
+	var cached []models.Item
+	var evicted []string
+	for i, change := range changes {
+		if change == nil {
+			continue
+		}
+		if change.after != nil {
+			cached = append(cached, *change.after)
+		} else {
+			evicted = append(evicted, change.before.ID)
+		}
+		middleware.RecordAudit(c, change.action, "item", results[i].ID, change.before, change.after)
+	}
+	database.SetItemsToCache(middleware.CurrentTenant(c), cached)
+	database.DeleteItemsFromCache(middleware.CurrentTenant(c), evicted)
+
+	response := BulkResponse{Mode: req.Mode, Results: results}
+	for _, result := range results {
+		if result.Error == "" {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+
+	status := http.StatusOK
+	if req.Mode == BulkModeAtomic && response.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	} else if response.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, response)
+}
+
+func executeBulkOperation(tx *gorm.DB, index int, op BulkOperation) (BulkResult, *bulkChange) {
+	result := BulkResult{Index: index, Op: op.Op, ID: op.ID}
+	fail := func(status int, message string) (BulkResult, *bulkChange) {
+		result.Status = status
+		result.Error = message
+		return result, nil
+	}
+
+	switch op.Op {
+	case "create":
+		if op.Item == nil {
+			return fail(http.StatusBadRequest, "item is required for create")
+		}
+		if err := validateItem(*op.Item); err != nil {
+			return fail(http.StatusBadRequest, err.Error())
+		}
+		item, status, err := createItemRecord(tx, *op.Item)
+		if err != nil {
+			return fail(status, err.Error())
+		}
+		result.ID = item.ID
+		result.Status = status
+		result.Data = &item
+		return result, &bulkChange{action: "create", after: &item}
+
+	case "update", "delete":
+		if op.ID == "" {
+			return fail(http.StatusBadRequest, "id is required for "+op.Op)
+		}
+
+		var existing models.Item
+		if err := tx.Preload("Tags").First(&existing, "id = ?", op.ID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fail(http.StatusNotFound, "Item not found")
+			}
+			return fail(http.StatusInternalServerError, "Database error")
+		}
+		if op.Version != nil && *op.Version != existing.Version {
+			return fail(http.StatusPreconditionFailed, "Item has been modified since it was retrieved")
+		}
+
+		if op.Op == "delete" {
+			if status, err := deleteItemRecord(tx, existing); err != nil {
+				return fail(status, err.Error())
+			}
+			result.Status = http.StatusOK
+			return result, &bulkChange{action: "delete", before: &existing}
+		}
+
+		if op.Item == nil {
+			return fail(http.StatusBadRequest, "item is required for update")
+		}
+		if err := validateItem(*op.Item); err != nil {
+			return fail(http.StatusBadRequest, err.Error())
+		}
+		item, status, err := updateItemRecord(tx, existing, *op.Item)
+		if err != nil {
+			return fail(status, err.Error())
+		}
+		result.Status = status
+		result.Data = &item
+		return result, &bulkChange{action: "update", before: &existing, after: &item}
+
+	default:
+		return fail(http.StatusBadRequest, "op must be one of create, update or delete")
+	}
+}
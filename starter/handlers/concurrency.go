@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"inventory_management/models"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errVersionConflict = errors.New("Item was modified concurrently, please retry")
+
+func computeETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func itemETag(item models.Item) string {
+	body, _ := json.Marshal(gin.H{"data": item})
+	return computeETag(body)
+}
+
+func setItemETag(c *gin.Context, item models.Item) {
+	c.Header("ETag", itemETag(item))
+}
+
+// respondConditionalJSON writes body with a strong ETag and Last-Modified
+// header, answering 304 Not Modified when the client's validators match.
+func respondConditionalJSON(c *gin.Context, body interface{}, lastModified time.Time) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode response"})
+		return
+	}
+
+	etag := computeETag(data)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(c, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+func notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" {
+		if strings.TrimSpace(ifNoneMatch) == "*" {
+			return true
+		}
+		for _, tag := range strings.Split(ifNoneMatch, ",") {
+			if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ifModifiedSince := c.GetHeader("If-Modified-Since"); ifModifiedSince != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		if err == nil && !lastModified.Truncate(time.Second).After(since) {
+			return true
+		}
+	}
+	return false
+}
+
+// requireIfMatch reports whether PUT and DELETE must carry If-Match
+// (REQUIRE_IF_MATCH). It is opt-in so existing clients keep working; by
+// default a write without the header is applied unconditionally.
+func requireIfMatch() bool {
+	return os.Getenv("REQUIRE_IF_MATCH") == "true"
+}
+
+// checkIfMatch validates the If-Match precondition against the current
+// item and writes the 428/412 response itself when it does not hold.
+func checkIfMatch(c *gin.Context, item models.Item) bool {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		if requireIfMatch() {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+			return false
+		}
+		return true
+	}
+
+	if strings.TrimSpace(ifMatch) == "*" {
+		return true
+	}
+
+	current := itemETag(item)
+	for _, tag := range strings.Split(ifMatch, ",") {
+		if strings.TrimSpace(tag) == current {
+			return true
+		}
+	}
+
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":           "Item has been modified since it was retrieved",
+		"current_version": item.Version,
+	})
+	return false
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"inventory_management/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CursorPaginationResponse struct {
+	Data         []models.Item     `json:"data"`
+	Limit        int               `json:"limit"`
+	NextCursor   string            `json:"next_cursor,omitempty"`
+	PrevCursor   string            `json:"prev_cursor,omitempty"`
+	HasNext      bool              `json:"has_next"`
+	HasPrev      bool              `json:"has_prev"`
+	Total        *int64            `json:"total,omitempty"`
+	Aggregations *ItemAggregations `json:"aggregations,omitempty"`
+
+	fields []string
+}
+
+func (r CursorPaginationResponse) MarshalJSON() ([]byte, error) {
+	type plain CursorPaginationResponse
+	if r.fields == nil {
+		return json.Marshal(plain(r))
+	}
+	data, err := projectItems(r.Data, r.fields)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		plain
+		Data []map[string]json.RawMessage `json:"data"`
+	}{plain(r), data})
+}
+
+// itemCursor marks a position in a sorted item list: the sort values and ID
+// of the row next to it. Prev cursors page backwards from that row.
+type itemCursor struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+	ID     string        `json:"id"`
+	Prev   bool          `json:"p,omitempty"`
+}
+
+var errInvalidCursor = errors.New("Invalid cursor")
+
+func getItemsByCursor(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	keys, err := itemListSort(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fields, err := itemListFields(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var cursor *itemCursor
+	if raw := c.Query("cursor"); raw != "" {
+		decoded, err := decodeItemCursor(raw, keys)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		cursor = &decoded
+	}
+
+	query, status, err := itemListQuery(c)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	aggregations, status, err := itemListAggregations(c, query)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := CursorPaginationResponse{Limit: limit, Aggregations: aggregations, fields: fields}
+	if c.Query("include_total") == "true" {
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count items"})
+			return
+		}
+		response.Total = &total
+	}
+
+	// Paging backwards walks the list in the opposite order from the
+	// cursor row and reverses the page afterwards. The ID breaks ties in
+	// the direction of the last sort key.
+	backward := cursor != nil && cursor.Prev
+	walk := make([]sortKey, len(keys), len(keys)+1)
+	for i, key := range keys {
+		walk[i] = sortKey{Column: key.Column, Desc: key.Desc != backward}
+	}
+	walk = append(walk, sortKey{Column: "id", Desc: walk[len(walk)-1].Desc})
+
+	if cursor != nil {
+		condition, args := keysetCondition(walk, append(cursor.Values, cursor.ID))
+		query = query.Where(condition, args...)
+	}
+
+	var items []models.Item
+	result := selectItemFields(query, fields, keys).Order(sortOrderClause(walk)).Limit(limit + 1).Find(&items)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch items"})
+		return
+	}
+
+	more := len(items) > limit
+	if more {
+		items = items[:limit]
+	}
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+		response.HasNext, response.HasPrev = true, more
+	} else {
+		response.HasNext, response.HasPrev = more, cursor != nil
+	}
+
+	response.Data = items
+	if len(items) > 0 {
+		if response.HasNext {
+			response.NextCursor = encodeItemCursor(newItemCursor(items[len(items)-1], keys, false))
+		}
+		if response.HasPrev {
+			response.PrevCursor = encodeItemCursor(newItemCursor(items[0], keys, true))
+		}
+	}
+
+	respondConditionalJSON(c, response, latestItemUpdate(c))
+}
+
+// keysetCondition selects the rows after values in the order given by
+// keys: (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ..., with < for desc keys.
+func keysetCondition(keys []sortKey, values []interface{}) (string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+	for i, key := range keys {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, keys[j].Column+" = ?")
+			args = append(args, values[j])
+		}
+		op := " > ?"
+		if key.Desc {
+			op = " < ?"
+		}
+		parts = append(parts, key.Column+op)
+		args = append(args, values[i])
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(clauses, " OR ") + ")", args
+}
+
+func newItemCursor(item models.Item, keys []sortKey, prev bool) itemCursor {
+	cursor := itemCursor{Sort: sortSpec(keys), ID: item.ID, Prev: prev}
+	for _, key := range keys {
+		cursor.Values = append(cursor.Values, itemSortValue(item, key.Column))
+	}
+	return cursor
+}
+
+func itemSortValue(item models.Item, column string) interface{} {
+	switch column {
+	case "stock":
+		return item.Stock
+	case "price":
+		return item.Price
+	case "status":
+		return string(item.Status)
+	case "created_at":
+		return item.CreatedAt
+	case "updated_at":
+		return item.UpdatedAt
+	default:
+		return item.Name
+	}
+}
+
+func encodeItemCursor(cursor itemCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeItemCursor checks the cursor was issued for the same sort and
+// converts its values back to the columns' types so they are bound as int,
+// float, string or time parameters.
+func decodeItemCursor(raw string, keys []sortKey) (itemCursor, error) {
+	var cursor itemCursor
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return cursor, errInvalidCursor
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&cursor); err != nil || cursor.ID == "" {
+		return cursor, errInvalidCursor
+	}
+	if cursor.Sort != sortSpec(keys) {
+		return cursor, errors.New("Cursor does not match the requested sort")
+	}
+	if len(cursor.Values) != len(keys) {
+		return cursor, errInvalidCursor
+	}
+
+	for i, key := range keys {
+		value, ok := decodeCursorValue(key.Column, cursor.Values[i])
+		if !ok {
+			return cursor, errInvalidCursor
+		}
+		cursor.Values[i] = value
+	}
+	return cursor, nil
+}
+
+func decodeCursorValue(column string, raw interface{}) (interface{}, bool) {
+	switch column {
+	case "stock":
+		number, ok := raw.(json.Number)
+		if !ok {
+			return nil, false
+		}
+		n, err := number.Int64()
+		return int(n), err == nil
+	case "price":
+		number, ok := raw.(json.Number)
+		if !ok {
+			return nil, false
+		}
+		f, err := number.Float64()
+		return f, err == nil
+	case "created_at", "updated_at":
+		text, ok := raw.(string)
+		if !ok {
+			return nil, false
+		}
+		t, err := time.Parse(time.RFC3339Nano, text)
+		return t, err == nil
+	default:
+		text, ok := raw.(string)
+		return text, ok
+	}
+}
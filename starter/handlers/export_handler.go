@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"inventory_management/database"
+	"inventory_management/models"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	formatNDJSON    = "ndjson"
+	exportChunkSize = 500
+)
+
+var exportColumns = []string{"id", "sku", "name", "stock", "price", "status", "tags", "version", "created_at", "updated_at"}
+
+// itemExporter writes items in one export format. Export streams the
+// response, so write errors can only be logged, not reported to the client.
+type itemExporter interface {
+	WriteItems(items []models.Item) error
+	Close() error
+}
+
+func ExportItems(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", formatCSV))
+	if format != formatCSV && format != formatNDJSON && format != formatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be 'csv', 'ndjson' or 'xlsx'"})
+		return
+	}
+
+	keys, err := itemListSort(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, status, err := itemListQuery(c)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := query.Order(sortOrderClause(keys)).Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch items"})
+		return
+	}
+	defer rows.Close()
+
+	filename := "inventory-" + time.Now().UTC().Format("20060102-150405") + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	exporter, err := newItemExporter(format, c.Writer)
+	if err != nil {
+		log.Println("[Export] Failed to start export:", err)
+		return
+	}
+
+	chunk := make([]models.Item, 0, exportChunkSize)
+	flush := func() error {
+		if err := loadExportTags(chunk); err != nil {
+			return err
+		}
+		if err := exporter.WriteItems(chunk); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		chunk = chunk[:0]
+		return nil
+	}
+
+	for rows.Next() {
+		var item models.Item
+		if err := database.DB.ScanRows(rows, &item); err != nil {
+			log.Println("[Export] Failed to read item:", err)
+			return
+		}
+		chunk = append(chunk, item)
+		if len(chunk) == exportChunkSize {
+			if err := flush(); err != nil {
+				log.Println("[Export] Failed to write items:", err)
+				return
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Println("[Export] Failed to read items:", err)
+		return
+	}
+	if err := flush(); err != nil {
+		log.Println("[Export] Failed to write items:", err)
+		return
+	}
+	if err := exporter.Close(); err != nil {
+		log.Println("[Export] Failed to finish export:", err)
+	}
+}
+
+func newItemExporter(format string, w gin.ResponseWriter) (itemExporter, error) {
+	switch format {
+	case formatNDJSON:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		return &ndjsonExporter{encoder: json.NewEncoder(w)}, nil
+	case formatXLSX:
+		w.Header().Set("Content-Type", xlsxContentType)
+		return newXLSXExporter(w)
+	default:
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		writer := csv.NewWriter(w)
+		return &csvExporter{writer: writer}, writer.Write(exportColumns)
+	}
+}
+
+// loadExportTags fills in the tags of a chunk of items with one query,
+// since rows read from a cursor cannot be preloaded.
+func loadExportTags(items []models.Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+
+	var links []struct {
+		ItemID string
+		TagID  string
+		Name   string
+	}
+	err := database.DB.Table("item_tags").
+		Select("item_tags.item_id, tags.id AS tag_id, tags.name").
+		Joins("JOIN tags ON tags.id = item_tags.tag_id").
+		Where("item_tags.item_id IN ?", ids).
+		Order("tags.name").
+		Scan(&links).Error
+	if err != nil {
+		return err
+	}
+
+	tags := make(map[string][]models.Tag, len(items))
+	for _, link := range links {
+		tags[link.ItemID] = append(tags[link.ItemID], models.Tag{ID: link.TagID, Name: link.Name})
+	}
+	for i := range items {
+		items[i].Tags = tags[items[i].ID]
+	}
+	return nil
+}
+
+func exportRecord(item models.Item) []string {
+	sku := ""
+	if item.SKU != nil {
+		sku = *item.SKU
+	}
+	tagNames := make([]string, len(item.Tags))
+	for i, tag := range item.Tags {
+		tagNames[i] = tag.Name
+	}
+	return []string{
+		item.ID,
+		sku,
+		item.Name,
+		strconv.Itoa(item.Stock),
+		strconv.FormatFloat(item.Price, 'f', -1, 64),
+		string(item.Status),
+		strings.Join(tagNames, ","),
+		strconv.Itoa(item.Version),
+		item.CreatedAt.UTC().Format(time.RFC3339),
+		item.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+type csvExporter struct {
+	writer *csv.Writer
+}
+
+func (e *csvExporter) WriteItems(items []models.Item) error {
+	for _, item := range items {
+		if err := e.writer.Write(exportRecord(item)); err != nil {
+			return err
+		}
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvExporter) Close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type ndjsonExporter struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExporter) WriteItems(items []models.Item) error {
+	for _, item := range items {
+		if err := e.encoder.Encode(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *ndjsonExporter) Close() error {
+	return nil
+}
+
+// xlsxExporter uses excelize's stream writer, which spills rows to a
+// temporary file; the workbook itself is only written out on Close.
+type xlsxExporter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXExporter(out io.Writer) (*xlsxExporter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter(file.GetSheetName(0))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	header := make([]interface{}, len(exportColumns))
+	for i, column := range exportColumns {
+		header[i] = column
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &xlsxExporter{out: out, file: file, stream: stream, row: 1}, nil
+}
+
+func (e *xlsxExporter) WriteItems(items []models.Item) error {
+	for _, item := range items {
+		e.row++
+		record := exportRecord(item)
+		values := make([]interface{}, len(record))
+		for i, value := range record {
+			values[i] = value
+		}
+		values[3] = item.Stock
+		values[4] = item.Price
+		values[7] = item.Version
+
+		cell, err := excelize.CoordinatesToCellName(1, e.row)
+		if err != nil {
+			return err
+		}
+		if err := e.stream.SetRow(cell, values); err != nil {
+			return fmt.Errorf("row %d: %w", e.row, err)
+		}
+	}
+	return nil
+}
+
+func (e *xlsxExporter) Close() error {
+	defer e.file.Close()
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+	return e.file.Write(e.out)
+}
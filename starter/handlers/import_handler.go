@@ -0,0 +1,550 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"inventory_management/database"
+	"inventory_management/middleware"
+	"inventory_management/models"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	importBatchSize     = 500
+	maxImportReportRows = 1000
+	maxImportFileSize   = 50 << 20
+)
+
+var (
+	importFields         = []string{"id", "sku", "name", "stock", "price", "status"}
+	errUnsupportedFormat = errors.New("unsupported format")
+)
+
+type ImportRowError struct {
+	Row    int    `json:"row"`
+	Column string `json:"column,omitempty"`
+	Error  string `json:"error"`
+}
+
+type ImportFieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type ImportRowChange struct {
+	Row     int                          `json:"row"`
+	Action  string                       `json:"action"`
+	ID      string                       `json:"id,omitempty"`
+	SKU     string                       `json:"sku,omitempty"`
+	Changes map[string]ImportFieldChange `json:"changes"`
+}
+
+type ImportSummary struct {
+	Rows      int `json:"rows"`
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Failed    int `json:"failed"`
+}
+
+type ImportResponse struct {
+	DryRun    bool              `json:"dry_run"`
+	Key       string            `json:"key"`
+	Summary   ImportSummary     `json:"summary"`
+	Errors    []ImportRowError  `json:"errors"`
+	Changes   []ImportRowChange `json:"changes"`
+	Truncated bool              `json:"truncated"`
+}
+
+type importRow struct {
+	number int
+	record []string
+	key    string
+	item   models.Item
+}
+
+type itemImporter struct {
+	c        *gin.Context
+	key      string
+	dryRun   bool
+	columns  map[string]int
+	seen     map[string]int
+	response ImportResponse
+}
+
+func ImportItems(c *gin.Context) {
+	key := c.DefaultQuery("key", "sku")
+	if key != "sku" && key != "id" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key must be 'sku' or 'id'"})
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be true or false"})
+		return
+	}
+
+	mapping, err := parseImportMapping(c.Query("mapping"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize)
+	source, filename, contentType, err := openImportSource(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := detectFormat(c.Query("format"), filename, contentType)
+	reader, err := newRowReader(format, source)
+	if err == errUnsupportedFormat {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "File must be CSV or XLSX"})
+		return
+	}
+	if err != nil {
+		respondImportReadError(c, err)
+		return
+	}
+	defer reader.Close()
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is empty"})
+		return
+	}
+	if err != nil {
+		respondImportReadError(c, err)
+		return
+	}
+
+	columns, err := mapImportColumns(header, mapping, key)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	importer := &itemImporter{
+		c:       c,
+		key:     key,
+		dryRun:  dryRun,
+		columns: columns,
+		seen:    make(map[string]int),
+		response: ImportResponse{
+			DryRun:  dryRun,
+			Key:     key,
+			Errors:  []ImportRowError{},
+			Changes: []ImportRowChange{},
+		},
+	}
+
+	rowNumber := 1
+	batch := make([]importRow, 0, importBatchSize)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		rowNumber++
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				importer.response.Summary.Rows++
+				importer.fail(rowNumber, "", parseErr.Err.Error())
+				continue
+			}
+			respondImportReadError(c, err)
+			return
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+
+		importer.response.Summary.Rows++
+		batch = append(batch, importRow{number: rowNumber, record: record})
+		if len(batch) == importBatchSize {
+			if err := importer.processBatch(batch); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import items"})
+				return
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := importer.processBatch(batch); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import items"})
+		return
+	}
+
+	c.JSON(http.StatusOK, importer.response)
+}
+
+// openImportSource returns the uploaded file without buffering it: either
+// the "file" part of a multipart form or the raw request body.
+func openImportSource(c *gin.Context) (io.Reader, string, string, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return c.Request.Body, "", mediaType, nil
+	}
+
+	multipartReader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, "", "", errors.New("Invalid multipart body")
+	}
+	for {
+		part, err := multipartReader.NextPart()
+		if err == io.EOF {
+			return nil, "", "", errors.New("Multipart body must contain a 'file' part")
+		}
+		if err != nil {
+			return nil, "", "", errors.New("Invalid multipart body")
+		}
+		if part.FormName() == "file" {
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			return part, part.FileName(), partType, nil
+		}
+	}
+}
+
+func respondImportReadError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import files may be at most 50 MB"})
+		return
+	}
+	if errors.Is(err, errXLSXTooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file: " + err.Error()})
+}
+
+func parseImportMapping(param string) (map[string]string, error) {
+	mapping := map[string]string{}
+	if param == "" {
+		return mapping, nil
+	}
+	if err := json.Unmarshal([]byte(param), &mapping); err != nil {
+		return nil, errors.New("mapping must be a JSON object of field to column name")
+	}
+	for field := range mapping {
+		if !isImportField(field) {
+			return nil, fmt.Errorf("Unknown field in mapping: %s", field)
+		}
+	}
+	return mapping, nil
+}
+
+// mapImportColumns resolves each item field to a column index. Header names
+// are matched case-insensitively, using the mapping where one is given.
+func mapImportColumns(header []string, mapping map[string]string, key string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, exists := index[name]; !exists {
+			index[name] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	for _, field := range importFields {
+		name, mapped := mapping[field]
+		if !mapped {
+			name = field
+		}
+		if i, ok := index[strings.ToLower(strings.TrimSpace(name))]; ok {
+			columns[field] = i
+		} else if mapped {
+			return nil, fmt.Errorf("Column %q mapped to %s not found", name, field)
+		}
+	}
+
+	for _, field := range []string{key, "name", "stock", "price"} {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("Missing required column: %s", field)
+		}
+	}
+	return columns, nil
+}
+
+func (imp *itemImporter) processBatch(batch []importRow) error {
+	rows := make([]importRow, 0, len(batch))
+	var keys []string
+	for _, row := range batch {
+		item, column, err := parseImportRecord(row.record, imp.columns)
+		if err != nil {
+			imp.fail(row.number, column, err.Error())
+			continue
+		}
+		if err := validateItem(item); err != nil {
+			imp.fail(row.number, "", err.Error())
+			continue
+		}
+
+		row.item = item
+		row.key = item.ID
+		if imp.key == "sku" {
+			if item.SKU == nil {
+				imp.fail(row.number, "sku", "SKU is required when importing by sku")
+				continue
+			}
+			row.key = *item.SKU
+		}
+		if row.key != "" {
+			if first, ok := imp.seen[row.key]; ok {
+				imp.fail(row.number, imp.key, fmt.Sprintf("Duplicate %s %s, first seen on row %d", imp.key, row.key, first))
+				continue
+			}
+			imp.seen[row.key] = row.number
+			keys = append(keys, row.key)
+		}
+		rows = append(rows, row)
+	}
+
+	existing := make(map[string]models.Item, len(keys))
+	if len(keys) > 0 {
+		var items []models.Item
+		if err := middleware.TenantDB(imp.c).Preload("Tags").Where(imp.key+" IN ?", keys).Find(&items).Error; err != nil {
+			return err
+		}
+		for _, item := range items {
+			if imp.key == "sku" {
+				existing[*item.SKU] = item
+			} else {
+				existing[item.ID] = item
+			}
+		}
+	}
+
+	if imp.dryRun {
+		for _, row := range rows {
+			imp.importRow(middleware.TenantDB(imp.c), row, existing)
+		}
+		return nil
+	}
+
+	var written []models.Item
+	err := middleware.TenantDB(imp.c).Transaction(func(tx *gorm.DB) error {
+		for _, row := range rows {
+			if item := imp.importRow(tx, row, existing); item != nil {
+				written = append(written, *item)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	database.SetItemsToCache(middleware.CurrentTenant(imp.c), written)
+	return nil
+}
+
+// importRow creates or updates the item for a single row, or in a dry run
+// only runs the checks the write would, and returns the stored item.
+func (imp *itemImporter) importRow(db *gorm.DB, row importRow, existing map[string]models.Item) *models.Item {
+	current, found := existing[row.key]
+	if !found {
+		if row.key != "" && imp.key == "id" {
+			imp.fail(row.number, "id", "Item not found")
+			return nil
+		}
+
+		change := ImportRowChange{Row: row.number, Action: "create", Changes: diffImportItem(nil, row.item)}
+		if row.item.SKU != nil {
+			change.SKU = *row.item.SKU
+		}
+		if imp.dryRun {
+			if _, err := checkSKUAvailable(db, row.item.SKU, ""); err != nil {
+				imp.fail(row.number, "sku", err.Error())
+				return nil
+			}
+			imp.record(change)
+			return nil
+		}
+
+		var created models.Item
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			created, _, err = createItemRecord(tx, row.item)
+			return err
+		})
+		if err != nil {
+			imp.fail(row.number, "", err.Error())
+			return nil
+		}
+		change.ID = created.ID
+		imp.record(change)
+		middleware.RecordAudit(imp.c, "import", "item", created.ID, nil, created)
+		return &created
+	}
+
+	item := row.item
+	if item.SKU == nil {
+		item.SKU = current.SKU
+	}
+	if item.Status == "" {
+		item.Status = current.Status
+	}
+
+	changes := diffImportItem(&current, item)
+	if len(changes) == 0 {
+		imp.response.Summary.Unchanged++
+		return nil
+	}
+
+	change := ImportRowChange{Row: row.number, Action: "update", ID: current.ID, Changes: changes}
+	if item.SKU != nil {
+		change.SKU = *item.SKU
+	}
+	if imp.dryRun {
+		if err := checkItemChange(current, item); err != nil {
+			imp.fail(row.number, "", err.Error())
+			return nil
+		}
+		if _, err := checkSKUAvailable(db, item.SKU, current.ID); err != nil {
+			imp.fail(row.number, "sku", err.Error())
+			return nil
+		}
+		imp.record(change)
+		return nil
+	}
+
+	var updated models.Item
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		updated, _, err = updateItemRecord(tx, current, item)
+		return err
+	})
+	if err != nil {
+		imp.fail(row.number, "", err.Error())
+		return nil
+	}
+	imp.record(change)
+	middleware.RecordAudit(imp.c, "import", "item", updated.ID, current, updated)
+	return &updated
+}
+
+func (imp *itemImporter) record(change ImportRowChange) {
+	if change.Action == "create" {
+		imp.response.Summary.Created++
+	} else {
+		imp.response.Summary.Updated++
+	}
+	if len(imp.response.Changes) < maxImportReportRows {
+		imp.response.Changes = append(imp.response.Changes, change)
+	} else {
+		imp.response.Truncated = true
+	}
+}
+
+func (imp *itemImporter) fail(row int, column, message string) {
+	imp.response.Summary.Failed++
+	if len(imp.response.Errors) < maxImportReportRows {
+		imp.response.Errors = append(imp.response.Errors, ImportRowError{Row: row, Column: column, Error: message})
+	} else {
+		imp.response.Truncated = true
+	}
+}
+
+func parseImportRecord(record []string, columns map[string]int) (models.Item, string, error) {
+	var item models.Item
+	value := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	item.ID = value("id")
+	if sku := value("sku"); sku != "" {
+		item.SKU = &sku
+	}
+	item.Name = value("name")
+	item.Status = models.ItemStatus(strings.ToLower(value("status")))
+
+	raw := value("stock")
+	if raw == "" {
+		return item, "stock", errors.New("Stock is required")
+	}
+	stock, err := strconv.Atoi(raw)
+	if err != nil {
+		return item, "stock", fmt.Errorf("Stock must be a whole number, got %q", raw)
+	}
+	item.Stock = stock
+
+	if raw := value("price"); raw != "" {
+		price, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return item, "price", fmt.Errorf("Price must be a number, got %q", raw)
+		}
+		item.Price = price
+	}
+	return item, "", nil
+}
+
+func diffImportItem(current *models.Item, item models.Item) map[string]ImportFieldChange {
+	changes := make(map[string]ImportFieldChange)
+	add := func(field string, from, to interface{}) {
+		if current == nil || from != to {
+			changes[field] = ImportFieldChange{From: from, To: to}
+		}
+	}
+
+	var fromSKU, toSKU interface{}
+	if item.SKU != nil {
+		toSKU = *item.SKU
+	}
+	if current == nil {
+		add("name", nil, item.Name)
+		add("stock", nil, item.Stock)
+		add("price", nil, item.Price)
+		status := item.Status
+		if status == "" {
+			status = models.ItemStatusActive
+		}
+		add("status", nil, status)
+		if toSKU != nil {
+			add("sku", nil, toSKU)
+		}
+		return changes
+	}
+
+	if current.SKU != nil {
+		fromSKU = *current.SKU
+	}
+	add("sku", fromSKU, toSKU)
+	add("name", current.Name, item.Name)
+	add("stock", current.Stock, item.Stock)
+	add("price", current.Price, item.Price)
+	add("status", current.Status, item.Status)
+	return changes
+}
+
+func isImportField(field string) bool {
+	for _, f := range importFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -1,28 +1,70 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"inventory_management/database"
+	"inventory_management/filter"
+	"inventory_management/middleware"
 	"inventory_management/models"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+var itemFilterFields = map[string]filter.Field{
+	"id":         {Column: "id", Type: filter.String},
+	"sku":        {Column: "sku", Type: filter.String},
+	"name":       {Column: "name", Type: filter.String},
+	"stock":      {Column: "stock", Type: filter.Int},
+	"price":      {Column: "price", Type: filter.Float},
+	"status":     {Column: "status", Type: filter.String, Enum: []string{"draft", "active", "discontinued", "archived"}},
+	"version":    {Column: "version", Type: filter.Int},
+	"created_at": {Column: "created_at", Type: filter.Time},
+	"updated_at": {Column: "updated_at", Type: filter.Time},
+}
+
 type PaginationResponse struct {
-	Data       []models.Item `json:"data"`
-	Total      int64         `json:"total"`
-	Page       int           `json:"page"`
-	PageSize   int           `json:"page_size"`
-	TotalPages int           `json:"total_pages"`
-	HasNext    bool          `json:"has_next"`
-	HasPrev    bool          `json:"has_prev"`
+	Data         []models.Item     `json:"data"`
+	Total        int64             `json:"total"`
+	Page         int               `json:"page"`
+	PageSize     int               `json:"page_size"`
+	TotalPages   int               `json:"total_pages"`
+	HasNext      bool              `json:"has_next"`
+	HasPrev      bool              `json:"has_prev"`
+	Aggregations *ItemAggregations `json:"aggregations,omitempty"`
+
+	fields []string
+}
+
+func (r PaginationResponse) MarshalJSON() ([]byte, error) {
+	type plain PaginationResponse
+	if r.fields == nil {
+		return json.Marshal(plain(r))
+	}
+	data, err := projectItems(r.Data, r.fields)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		plain
+		Data []map[string]json.RawMessage `json:"data"`
+	}{plain(r), data})
 }
 
 func GetAllItems(c *gin.Context) {
+	if c.Query("cursor") != "" || c.Query("limit") != "" {
+		getItemsByCursor(c)
+		return
+	}
+
 	var items []models.Item
 	var total int64
 
@@ -36,35 +78,28 @@ func GetAllItems(c *gin.Context) {
 		pageSize = 10
 	}
 
-	sortBy := c.DefaultQuery("sort_by", "name")
-	sortOrder := c.DefaultQuery("sort_order", "asc")
-	validSortFields := map[string]bool{
-		"name":  true,
-		"stock": true,
-		"price": true,
-	}
-
-	if !validSortFields[sortBy] {
-		sortBy = "name"
+	keys, err := itemListSort(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if sortOrder != "asc" && sortOrder != "desc" {
-		sortOrder = "asc"
+	fields, err := itemListFields(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	minStock := c.Query("min_stock")
-	nameFilter := c.Query("name")
-
-	query := database.DB.Model(&models.Item{})
-
-	if minStock != "" {
-		if minStockInt, err := strconv.Atoi(minStock); err == nil {
-			query = query.Where("stock >= ?", minStockInt)
-		}
+	query, status, err := itemListQuery(c)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
 	}
 
-	if nameFilter != "" {
-		query = query.Where("LOWER(name) LIKE ?", "%"+strings.ToLower(nameFilter)+"%")
+	aggregations, status, err := itemListAggregations(c, query)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
 	}
 
 	if err := query.Count(&total).Error; err != nil {
@@ -73,9 +108,8 @@ func GetAllItems(c *gin.Context) {
 	}
 
 	offset := (page - 1) * pageSize
-	orderClause := sortBy + " " + sortOrder
 
-	result := query.Order(orderClause).Limit(pageSize).Offset(offset).Find(&items)
+	result := selectItemFields(query, fields, keys).Order(sortOrderClause(keys)).Limit(pageSize).Offset(offset).Find(&items)
 
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch items"})
@@ -87,28 +121,120 @@ func GetAllItems(c *gin.Context) {
 	hasPrev := page > 1
 
 	response := PaginationResponse{
-		Data:       items,
-		Total:      total,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: totalPages,
-		HasNext:    hasNext,
-		HasPrev:    hasPrev,
+		Data:         items,
+		Total:        total,
+		Page:         page,
+		PageSize:     pageSize,
+		TotalPages:   totalPages,
+		HasNext:      hasNext,
+		HasPrev:      hasPrev,
+		Aggregations: aggregations,
+		fields:       fields,
+	}
+
+	respondConditionalJSON(c, response, latestItemUpdate(c))
+}
+
+// checkIncludeDeleted limits deleted items to those who may restore them.
+func checkIncludeDeleted(c *gin.Context) (int, error) {
+	if middleware.HasPermission(c, middleware.PermInventoryDelete) {
+		return http.StatusOK, nil
+	}
+	if !middleware.IsAuthenticated(c) {
+		return http.StatusUnauthorized, errors.New("Authentication required to include deleted items")
+	}
+	return http.StatusForbidden, errors.New("Missing permission: " + string(middleware.PermInventoryDelete))
+}
+
+// itemListQuery applies the list filters shared by GetAllItems and
+// ExportItems; on failure it returns the HTTP status to respond with.
+func itemListQuery(c *gin.Context) (*gorm.DB, int, error) {
+	minStock := c.Query("min_stock")
+	nameFilter := c.Query("name")
+	tagFilter := parseTagFilter(c.Query("tags"))
+	tagMatch := c.DefaultQuery("tags_match", "any")
+
+	if tagMatch != "any" && tagMatch != "all" {
+		return nil, http.StatusBadRequest, errors.New("tags_match must be 'any' or 'all'")
+	}
+
+	statuses, err := parseStatusFilter(c.DefaultQuery("status", string(models.ItemStatusActive)))
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	includeDeleted := c.Query("include_deleted") == "true"
+	if includeDeleted {
+		if status, err := checkIncludeDeleted(c); err != nil {
+			return nil, status, err
+		}
+	}
+
+	query := middleware.TenantDB(c).Model(&models.Item{})
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
 	}
 
-	c.JSON(http.StatusOK, response)
+	if minStock != "" {
+		if minStockInt, err := strconv.Atoi(minStock); err == nil {
+			query = query.Where("stock >= ?", minStockInt)
+		}
+	}
+
+	if nameFilter != "" {
+		query = query.Where("LOWER(name) LIKE ?", "%"+strings.ToLower(nameFilter)+"%")
+	}
+
+	if len(tagFilter) > 0 {
+		tagged := middleware.TenantDB(c).Table("item_tags").
+			Select("item_tags.item_id").
+			Joins("JOIN tags ON tags.id = item_tags.tag_id").
+			Where("tags.name IN ?", tagFilter)
+		if tagMatch == "all" {
+			tagged = tagged.Group("item_tags.item_id").Having("COUNT(DISTINCT tags.name) = ?", len(tagFilter))
+		}
+		query = query.Where("id IN (?)", tagged)
+	}
+
+	if expression := c.Query("filter"); expression != "" {
+		node, err := filter.Parse(expression, itemFilterFields)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		condition, args := filter.SQL(node)
+		query = query.Where(condition, args...)
+	}
+
+	return query, http.StatusOK, nil
 }
 
 func GetItemByID(c *gin.Context) {
 	id := c.Param("id")
 	var item models.Item
 
-	if database.GetItemFromCache(id, &item) {
-		c.JSON(http.StatusOK, gin.H{"data": item})
+	includeDeleted := c.Query("include_deleted") == "true"
+	if includeDeleted {
+		if status, err := checkIncludeDeleted(c); err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if !includeDeleted && database.GetItemFromCache(middleware.CurrentTenant(c), id, &item) {
+		respondConditionalJSON(c, gin.H{"data": item}, item.UpdatedAt)
 		return
 	}
 
-	result := database.DB.First(&item, "id = ?", id)
+	query := middleware.TenantDB(c).Preload("Tags")
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+
+	result := query.First(&item, "id = ?", id)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
@@ -118,8 +244,10 @@ func GetItemByID(c *gin.Context) {
 		return
 	}
 
-	database.SetItemToCache(id, item)
-	c.JSON(http.StatusOK, gin.H{"data": item})
+	if !item.DeletedAt.Valid {
+		database.SetItemToCache(middleware.CurrentTenant(c), id, item)
+	}
+	respondConditionalJSON(c, gin.H{"data": item}, item.UpdatedAt)
 }
 
 func CreateItem(c *gin.Context) {
@@ -130,25 +258,20 @@ func CreateItem(c *gin.Context) {
 		return
 	}
 
-	if item.Stock < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Stock cannot be negative"})
-		return
-	}
-
-	if item.Price <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Price must be greater than 0"})
+	if err := checkItemRules(item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	item.ID = uuid.New().String()
-
-	result := database.DB.Create(&item)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create item"})
+	item, status, err := createItemRecord(middleware.TenantDB(c), item)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
-	database.SetItemToCache(item.ID, item)
+	database.SetItemToCache(middleware.CurrentTenant(c), item.ID, item)
+	middleware.RecordAudit(c, "create", "item", item.ID, nil, item)
+	setItemETag(c, item)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Item created successfully",
@@ -159,7 +282,7 @@ func CreateItem(c *gin.Context) {
 func UpdateItem(c *gin.Context) {
 	id := c.Param("id")
 	var existingItem models.Item
-	result := database.DB.First(&existingItem, "id = ?", id)
+	result := middleware.TenantDB(c).Preload("Tags").First(&existingItem, "id = ?", id)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
@@ -169,30 +292,38 @@ func UpdateItem(c *gin.Context) {
 		return
 	}
 
+	if !checkIfMatch(c, existingItem) {
+		return
+	}
+
 	var updatedItem models.Item
 	if err := c.ShouldBindJSON(&updatedItem); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if updatedItem.Stock < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Stock cannot be negative"})
+	if err := checkItemRules(updatedItem); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if updatedItem.Price <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Price must be greater than 0"})
+	applyItemUpdate(c, existingItem, updatedItem)
+}
+
+func applyItemUpdate(c *gin.Context, existingItem, updatedItem models.Item) {
+	if !authorizeItemUpdate(c, existingItem, updatedItem) {
 		return
 	}
 
-	updatedItem.ID = existingItem.ID
-	result = database.DB.Save(&updatedItem)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update item"})
+	updatedItem, status, err := updateItemRecord(middleware.TenantDB(c), existingItem, updatedItem)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
-	database.SetItemToCache(updatedItem.ID, updatedItem)
+	database.SetItemToCache(middleware.CurrentTenant(c), updatedItem.ID, updatedItem)
+	middleware.RecordAudit(c, "update", "item", updatedItem.ID, existingItem, updatedItem)
+	setItemETag(c, updatedItem)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Item updated successfully",
@@ -200,22 +331,243 @@ func UpdateItem(c *gin.Context) {
 	})
 }
 
+// authorizeItemUpdate checks the permission for each field the update
+// changes, so clerks can set stock through PUT and PATCH without being
+// able to touch prices.
+func authorizeItemUpdate(c *gin.Context, existing, updated models.Item) bool {
+	var required []middleware.Permission
+	if updated.Stock != existing.Stock {
+		required = append(required, middleware.PermInventoryStock)
+	}
+	if updated.Price != existing.Price {
+		required = append(required, middleware.PermInventoryPrice)
+	}
+	if updated.Name != existing.Name || !equalSKU(updated.SKU, existing.SKU) ||
+		(updated.Status != "" && updated.Status != existing.Status) {
+		required = append(required, middleware.PermInventoryWrite)
+	}
+
+	for _, permission := range required {
+		if !middleware.HasPermission(c, permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Missing permission: " + string(permission)})
+			return false
+		}
+	}
+	return true
+}
+
+func equalSKU(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 func DeleteItem(c *gin.Context) {
 	id := c.Param("id")
 	var item models.Item
 
-	result := database.DB.Where("id = ?", id).Delete(&item)
+	result := middleware.TenantDB(c).Preload("Tags").First(&item, "id = ?", id)
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete item"})
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
 		return
 	}
 
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	if !checkIfMatch(c, item) {
+		return
+	}
+
+	if status, err := deleteItemRecord(middleware.TenantDB(c), item); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
-	database.DeleteItemFromCache(id)
+	database.DeleteItemFromCache(middleware.CurrentTenant(c), id)
+	middleware.RecordAudit(c, "delete", "item", id, item, nil)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Item deleted successfully"})
 }
+
+// createItemRecord, updateItemRecord and deleteItemRecord hold the write
+// logic shared by the single-item and bulk endpoints. On failure they
+// return the HTTP status code that describes the error.
+func createItemRecord(db *gorm.DB, item models.Item) (models.Item, int, error) {
+	if item.Status == "" {
+		item.Status = models.ItemStatusActive
+	}
+
+	item.ID = uuid.New().String()
+	item.Version = 1
+	item.Tags = nil
+	item.CreatedAt = time.Time{}
+	item.UpdatedAt = time.Time{}
+	item.DeletedAt = gorm.DeletedAt{}
+
+	if status, err := checkSKUAvailable(db, item.SKU, item.ID); err != nil {
+		return item, status, err
+	}
+
+	if err := db.Create(&item).Error; err != nil {
+		return item, http.StatusInternalServerError, errors.New("Failed to create item")
+	}
+
+	if err := db.Preload("Tags").First(&item, "id = ?", item.ID).Error; err != nil {
+		return item, http.StatusInternalServerError, errors.New("Database error")
+	}
+	return item, http.StatusCreated, nil
+}
+
+func updateItemRecord(db *gorm.DB, existingItem, updatedItem models.Item) (models.Item, int, error) {
+	if updatedItem.Status == "" {
+		updatedItem.Status = existingItem.Status
+	}
+
+	if err := checkItemChange(existingItem, updatedItem); err != nil {
+		return updatedItem, http.StatusConflict, err
+	}
+
+	if status, err := checkSKUAvailable(db, updatedItem.SKU, existingItem.ID); err != nil {
+		return updatedItem, status, err
+	}
+
+	updatedItem.ID = existingItem.ID
+	updatedItem.Version = existingItem.Version + 1
+	updatedItem.Tags = nil
+	updatedItem.DeletedAt = gorm.DeletedAt{}
+	result := db.Model(&updatedItem).
+		Where("version = ?", existingItem.Version).
+		Select("sku", "name", "stock", "price", "status", "version", "updated_at").
+		Updates(&updatedItem)
+	if result.Error != nil {
+		return updatedItem, http.StatusInternalServerError, errors.New("Failed to update item")
+	}
+
+	if result.RowsAffected == 0 {
+		return updatedItem, http.StatusPreconditionFailed, errVersionConflict
+	}
+
+	if err := db.Preload("Tags").First(&updatedItem, "id = ?", existingItem.ID).Error; err != nil {
+		return updatedItem, http.StatusInternalServerError, errors.New("Database error")
+	}
+	return updatedItem, http.StatusOK, nil
+}
+
+func deleteItemRecord(db *gorm.DB, item models.Item) (int, error) {
+	result := db.Model(&models.Item{}).
+		Where("id = ? AND version = ?", item.ID, item.Version).
+		Updates(map[string]interface{}{"deleted_at": time.Now(), "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return http.StatusInternalServerError, errors.New("Failed to delete item")
+	}
+
+	if result.RowsAffected == 0 {
+		return http.StatusPreconditionFailed, errVersionConflict
+	}
+	return http.StatusOK, nil
+}
+
+func latestItemUpdate(c *gin.Context) time.Time {
+	var latest models.Item
+	if err := middleware.TenantDB(c).Unscoped().Select("updated_at").Order("updated_at desc").Take(&latest).Error; err != nil {
+		return time.Time{}
+	}
+	return latest.UpdatedAt
+}
+
+func validateItem(item models.Item) error {
+	if err := binding.Validator.ValidateStruct(item); err != nil {
+		return err
+	}
+	return checkItemRules(item)
+}
+
+func checkItemRules(item models.Item) error {
+	if item.Stock < 0 {
+		return errors.New("Stock cannot be negative")
+	}
+	if item.Price <= 0 {
+		return errors.New("Price must be greater than 0")
+	}
+	return nil
+}
+
+func checkItemChange(existing, updated models.Item) error {
+	if !existing.Status.CanTransitionTo(updated.Status) {
+		return fmt.Errorf("Invalid status transition from %s to %s", existing.Status, updated.Status)
+	}
+	if updated.Stock < existing.Stock && !existing.Status.AllowsStockDecrement() {
+		return fmt.Errorf("Stock cannot be decreased for %s items", existing.Status)
+	}
+	if updated.Stock > existing.Stock && !existing.Status.AllowsStockIncrement() {
+		return fmt.Errorf("Stock cannot be increased for %s items", existing.Status)
+	}
+	return nil
+}
+
+// checkSKUAvailable reports a conflict when another item, including a
+// soft-deleted one, already uses the SKU.
+func checkSKUAvailable(db *gorm.DB, sku *string, itemID string) (int, error) {
+	if sku == nil {
+		return http.StatusOK, nil
+	}
+	var count int64
+	if err := db.Unscoped().Model(&models.Item{}).Where("sku = ? AND id <> ?", *sku, itemID).Count(&count).Error; err != nil {
+		return http.StatusInternalServerError, errors.New("Database error")
+	}
+	if count > 0 {
+		return http.StatusConflict, fmt.Errorf("SKU %s is already in use", *sku)
+	}
+	return http.StatusOK, nil
+}
+
+func parseStatusFilter(param string) ([]models.ItemStatus, error) {
+	if param == "all" {
+		return nil, nil
+	}
+
+	var statuses []models.ItemStatus
+	for _, part := range strings.Split(param, ",") {
+		status := models.ItemStatus(strings.TrimSpace(part))
+		if !status.IsValid() {
+			return nil, fmt.Errorf("Invalid status filter: %s", part)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func RestoreItem(c *gin.Context) {
+	id := c.Param("id")
+
+	result := middleware.TenantDB(c).Unscoped().Model(&models.Item{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore item"})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deleted item not found"})
+		return
+	}
+
+	var item models.Item
+	if err := middleware.TenantDB(c).Preload("Tags").First(&item, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	database.SetItemToCache(middleware.CurrentTenant(c), id, item)
+	middleware.RecordAudit(c, "restore", "item", id, nil, item)
+	setItemETag(c, item)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Item restored successfully",
+		"data":    item,
+	})
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"inventory_management/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS publishes the public keys that verify access tokens so other
+// services can check them without a shared secret. It is empty for HS256.
+func GetJWKS(c *gin.Context) {
+	keys, err := middleware.JWKS()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load signing keys"})
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"inventory_management/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxSortKeys = 5
+
+type sortKey struct {
+	Column string
+	Desc   bool
+}
+
+var itemSortFields = map[string]bool{
+	"name":       true,
+	"stock":      true,
+	"price":      true,
+	"status":     true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+var itemFields = []string{"id", "sku", "name", "stock", "price", "status", "version", "tags", "created_at", "updated_at", "deleted_at"}
+
+// itemListSort reads `sort` (e.g. "-stock,name"), falling back to the
+// single-key sort_by and sort_order parameters when it is absent.
+func itemListSort(c *gin.Context) ([]sortKey, error) {
+	if raw := c.Query("sort"); raw != "" {
+		var keys []sortKey
+		seen := make(map[string]bool)
+		for _, part := range strings.Split(raw, ",") {
+			part = strings.TrimSpace(part)
+			key := sortKey{Column: strings.TrimPrefix(strings.TrimPrefix(part, "-"), "+"), Desc: strings.HasPrefix(part, "-")}
+			if !itemSortFields[key.Column] {
+				return nil, fmt.Errorf("Unknown sort field: %s", key.Column)
+			}
+			if seen[key.Column] {
+				return nil, fmt.Errorf("Duplicate sort field: %s", key.Column)
+			}
+			seen[key.Column] = true
+			keys = append(keys, key)
+		}
+		if len(keys) > maxSortKeys {
+			return nil, fmt.Errorf("At most %d sort fields are allowed", maxSortKeys)
+		}
+		return keys, nil
+	}
+
+	sortBy := c.DefaultQuery("sort_by", "name")
+	sortOrder := c.DefaultQuery("sort_order", "asc")
+	validSortFields := map[string]bool{
+		"name":  true,
+		"stock": true,
+		"price": true,
+	}
+
+	if !validSortFields[sortBy] {
+		sortBy = "name"
+	}
+
+	return []sortKey{{Column: sortBy, Desc: sortOrder == "desc"}}, nil
+}
+
+func sortOrderClause(keys []sortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		if key.Desc {
+			parts[i] = key.Column + " desc"
+		} else {
+			parts[i] = key.Column + " asc"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortSpec is the canonical form of keys, used to tie cursors to a sort.
+func sortSpec(keys []sortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		if key.Desc {
+			parts[i] = "-" + key.Column
+		} else {
+			parts[i] = key.Column
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// itemListFields reads the `fields` sparse fieldset; nil means all fields.
+func itemListFields(c *gin.Context) ([]string, error) {
+	raw := c.Query("fields")
+	if raw == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if !isItemField(field) {
+			return nil, fmt.Errorf("Unknown field: %s", field)
+		}
+		fields = append(fields, field)
+	}
+	return uniqueStrings(fields), nil
+}
+
+// selectItemFields limits the SELECT to the requested columns plus the ID
+// and sort columns, which pagination needs, and only preloads tags when
+// they were asked for.
+func selectItemFields(query *gorm.DB, fields []string, keys []sortKey) *gorm.DB {
+	if fields == nil {
+		return query.Preload("Tags")
+	}
+
+	columns := []string{"id"}
+	preloadTags := false
+	for _, field := range fields {
+		if field == "tags" {
+			preloadTags = true
+		} else {
+			columns = append(columns, field)
+		}
+	}
+	for _, key := range keys {
+		columns = append(columns, key.Column)
+	}
+
+	query = query.Select(uniqueStrings(columns))
+	if preloadTags {
+		query = query.Preload("Tags")
+	}
+	return query
+}
+
+// projectItems keeps only the requested fields of each item, using the
+// same JSON names as models.Item.
+func projectItems(items []models.Item, fields []string) ([]map[string]json.RawMessage, error) {
+	projected := make([]map[string]json.RawMessage, len(items))
+	for i, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+		projected[i] = make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if value, ok := all[field]; ok {
+				projected[i][field] = value
+			}
+		}
+	}
+	return projected, nil
+}
+
+func isItemField(field string) bool {
+	for _, f := range itemFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"inventory_management/database"
+	"inventory_management/middleware"
+	"inventory_management/models"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultMFAIssuer = "Inventory Management"
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableMFARequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// EnrollMFA starts two-factor enrolment for the current user. The secret
+// is returned as text, as an otpauth:// URI and as a QR code PNG (base64)
+// for authenticator apps; it is only used once VerifyMFA confirms it.
+// Starting again replaces an unconfirmed secret.
+func EnrollMFA(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if user.ExternalID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Accounts that sign in through the identity provider use its two-factor authentication"})
+		return
+	}
+	if user.MFAEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	issuer := os.Getenv("MFA_ISSUER")
+	if issuer == "" {
+		issuer = defaultMFAIssuer
+	}
+	key, err := user.GenerateTOTPSecret(issuer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+	var qr bytes.Buffer
+	img, err := key.Image(256, 256)
+	if err == nil {
+		err = png.Encode(&qr, img)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate QR code"})
+		return
+	}
+	if err := middleware.TenantDB(c).Model(&user).Update("mfa_secret", user.MFASecret).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+
+	middleware.RecordAudit(c, "enroll_mfa", "user", user.ID, nil, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      key.Secret(),
+		"otpauth_uri": key.URL(),
+		"qr_png":      base64.StdEncoding.EncodeToString(qr.Bytes()),
+	})
+}
+
+// VerifyMFA confirms the enrolment with a code from the authenticator and
+// returns the recovery codes, which are not shown again. Other sessions of
+// the user were started without the second factor and are ended; the
+// current access token stays valid until it expires, but under
+// REQUIRE_MFA_FOR_ADMINS an admin has to log in again before writing.
+func VerifyMFA(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if user.MFAEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	if user.MFASecret == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Start the enrolment at /api/v1/mfa/enroll first"})
+		return
+	}
+	step, valid := user.CheckTOTP(req.Code, time.Now())
+	if !valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": database.ErrSecondFactorInvalid.Error()})
+		return
+	}
+
+	result := middleware.TenantDB(c).Model(&models.User{}).
+		Where("id = ? AND mfa_secret = ? AND mfa_enabled = ?", user.ID, user.MFASecret, false).
+		Updates(map[string]interface{}{"mfa_enabled": true, "mfa_last_step": step})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "The enrolment was restarted; scan the new QR code"})
+		return
+	}
+	codes, err := database.CreateRecoveryCodes(database.DB, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recovery codes"})
+		return
+	}
+	if err := database.RevokeUserRefreshTokens(database.DB, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	middleware.RecordAudit(c, "enable_mfa", "user", user.ID, nil, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes,
+// e.g. after using some of them. It takes a TOTP code.
+func RegenerateRecoveryCodes(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !user.MFAEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	if !checkSecondFactor(c, user, req.Code, "") {
+		return
+	}
+
+	codes, err := database.CreateRecoveryCodes(database.DB, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recovery codes"})
+		return
+	}
+	middleware.RecordAudit(c, "regenerate_recovery_codes", "user", user.ID, nil, nil)
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// DisableMFA turns off two-factor authentication for the current user,
+// who proves it is them with a TOTP or recovery code.
+func DisableMFA(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var req DisableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code or recovery_code is required"})
+		return
+	}
+	if !user.MFAEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	if !checkSecondFactor(c, user, req.Code, req.RecoveryCode) {
+		return
+	}
+
+	if err := database.DisableMFA(middleware.TenantDB(c), user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+	middleware.RecordAudit(c, "disable_mfa", "user", user.ID, nil, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// ResetUserMFA removes the second factor of a user who lost both their
+// authenticator and their recovery codes, and ends their sessions.
+func ResetUserMFA(c *gin.Context) {
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	if err := database.DisableMFA(middleware.TenantDB(c), user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+	if err := database.RevokeUserRefreshTokens(database.DB, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	middleware.RecordAudit(c, "reset_mfa", "user", user.ID, nil, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication reset"})
+}
+
+// verifySecondFactor accepts an unused recovery code or a TOTP code that
+// is newer than the last one accepted, so neither can be replayed.
+func verifySecondFactor(c *gin.Context, user models.User, code, recoveryCode string) error {
+	if recoveryCode != "" {
+		if err := database.UseRecoveryCode(database.DB, user.ID, recoveryCode); err != nil {
+			return err
+		}
+		middleware.RecordAudit(c, "use_recovery_code", "user", user.ID, nil, nil)
+		return nil
+	}
+	step, ok := user.CheckTOTP(code, time.Now())
+	if !ok {
+		return database.ErrSecondFactorInvalid
+	}
+	return database.ConsumeTOTPStep(database.DB, user.ID, step)
+}
+
+// checkSecondFactor is verifySecondFactor for authenticated requests; it
+// responds itself when the code is not accepted. Wrong codes count
+// towards the login lockout, so a stolen session cannot guess them either.
+func checkSecondFactor(c *gin.Context, user models.User, code, recoveryCode string) bool {
+	throttles := loginThrottles(c, user.Username)
+	if retryAfter := loginLockout(throttles); retryAfter > 0 {
+		respondLockedOut(c, retryAfter)
+		return false
+	}
+
+	err := verifySecondFactor(c, user, code, recoveryCode)
+	switch {
+	case err == database.ErrSecondFactorInvalid:
+		recordLoginFailure(c, user.Username, throttles)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	default:
+		database.ResetLoginFailures(throttles[0].key)
+	}
+	return err == nil
+}
+
+// currentUser loads the account behind the request's token. API keys
+// have none and are refused.
+func currentUser(c *gin.Context) (models.User, bool) {
+	var user models.User
+	username := middleware.CurrentUsername(c)
+	if username == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot use two-factor authentication"})
+		return user, false
+	}
+	if err := middleware.TenantDB(c).Where("username = ?", username).First(&user).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return user, false
+	}
+	return user, true
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"inventory_management/database"
+	"inventory_management/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oidcCookieName = "oidc_auth"
+	oidcCookiePath = "/api/v1/auth/oidc"
+	oidcCookieAge  = 600
+)
+
+// oidcAuthRequest is kept in a short-lived HttpOnly cookie between the
+// redirect to the identity provider and the callback, so any instance can
+// finish the login.
+type oidcAuthRequest struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// OIDCLogin redirects the browser to the identity provider using the
+// authorization code flow with PKCE.
+func OIDCLogin(c *gin.Context) {
+	if !middleware.OIDCEnabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": middleware.ErrOIDCDisabled.Error()})
+		return
+	}
+
+	request := oidcAuthRequest{State: randomToken(), Nonce: randomToken(), Verifier: oauth2.GenerateVerifier()}
+	url, err := middleware.OIDCAuthCodeURL(c.Request.Context(), request.State, request.Nonce, request.Verifier)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider is unavailable"})
+		return
+	}
+
+	data, _ := json.Marshal(request)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcCookieName, base64.RawURLEncoding.EncodeToString(data), oidcCookieAge, oidcCookiePath, "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, url)
+}
+
+// OIDCCallback finishes the login: it checks the state, redeems the code,
+// validates the ID token and responds with this service's own tokens.
+func OIDCCallback(c *gin.Context) {
+	if !middleware.OIDCEnabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": middleware.ErrOIDCDisabled.Error()})
+		return
+	}
+	if reason := c.Query("error"); reason != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Identity provider denied the login: " + reason})
+		return
+	}
+
+	request, err := readOIDCAuthRequest(c)
+	c.SetCookie(oidcCookieName, "", -1, oidcCookiePath, "", c.Request.TLS != nil, true)
+	if err != nil || c.Query("state") == "" || c.Query("state") != request.State {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired login state"})
+		return
+	}
+
+	identity, err := middleware.OIDCExchange(c.Request.Context(), c.Query("code"), request.Verifier, request.Nonce)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Identity provider login failed: " + err.Error()})
+		return
+	}
+
+	user, err := database.UpsertExternalUser(database.DB, identity.ExternalID, identity.Username, identity.Role, identity.Tenant)
+	if err != nil {
+		if errors.Is(err, database.ErrUsernameTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+	if user.Disabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return
+	}
+
+	respondWithTokens(c, user, nil, identity.MFA)
+}
+
+func readOIDCAuthRequest(c *gin.Context) (oidcAuthRequest, error) {
+	var request oidcAuthRequest
+	cookie, err := c.Cookie(oidcCookieName)
+	if err != nil {
+		return request, err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cookie)
+	if err != nil {
+		return request, err
+	}
+	return request, json.Unmarshal(data, &request)
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
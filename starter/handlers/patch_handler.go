@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"inventory_management/middleware"
+	"inventory_management/models"
+	"io"
+	"net/http"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+	maxPatchBodySize      = 1 << 20
+)
+
+// itemPatchDocument is the JSON representation patches are applied to;
+// only these fields of an item can be changed through PATCH.
+type itemPatchDocument struct {
+	SKU    *string           `json:"sku"`
+	Name   string            `json:"name"`
+	Stock  int               `json:"stock"`
+	Price  float64           `json:"price"`
+	Status models.ItemStatus `json:"status"`
+}
+
+func PatchItem(c *gin.Context) {
+	id := c.Param("id")
+
+	contentType := c.ContentType()
+	if contentType != mergePatchContentType && contentType != jsonPatchContentType {
+		c.Header("Accept-Patch", mergePatchContentType+", "+jsonPatchContentType)
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": "Content-Type must be " + mergePatchContentType + " or " + jsonPatchContentType,
+		})
+		return
+	}
+
+	var existingItem models.Item
+	result := middleware.TenantDB(c).Preload("Tags").First(&existingItem, "id = ?", id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	if !checkIfMatch(c, existingItem) {
+		return
+	}
+
+	patchBody, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPatchBodySize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	original, _ := json.Marshal(itemPatchDocument{
+		SKU:    existingItem.SKU,
+		Name:   existingItem.Name,
+		Stock:  existingItem.Stock,
+		Price:  existingItem.Price,
+		Status: existingItem.Status,
+	})
+
+	var patched []byte
+	if contentType == mergePatchContentType {
+		if !json.Valid(patchBody) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid merge patch document"})
+			return
+		}
+		patched, err = jsonpatch.MergePatch(original, patchBody)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid merge patch document: " + err.Error()})
+			return
+		}
+	} else {
+		patch, err := jsonpatch.DecodePatch(patchBody)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON patch document: " + err.Error()})
+			return
+		}
+		patched, err = patch.Apply(original)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Failed to apply JSON patch: " + err.Error()})
+			return
+		}
+	}
+
+	var doc itemPatchDocument
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&doc); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Patched item is invalid: " + err.Error()})
+		return
+	}
+
+	updatedItem := models.Item{
+		SKU:    doc.SKU,
+		Name:   doc.Name,
+		Stock:  doc.Stock,
+		Price:  doc.Price,
+		Status: doc.Status,
+	}
+
+	if err := validateItem(updatedItem); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	applyItemUpdate(c, existingItem, updatedItem)
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"html"
+	"inventory_management/database"
+	"inventory_management/middleware"
+	"inventory_management/models"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxSearchQueryLength = 200
+	maxSearchTerms       = 10
+	// searchSimilarityThreshold matches the pg_trgm default for the <%
+	// operator so both search backends accept the same typos.
+	searchSimilarityThreshold = 0.6
+)
+
+type SearchResult struct {
+	models.Item
+	Score     float64 `json:"score"`
+	Highlight string  `json:"highlight"`
+}
+
+type SearchResponse struct {
+	Query string         `json:"query"`
+	Data  []SearchResult `json:"data"`
+	Limit int            `json:"limit"`
+}
+
+type searchHit struct {
+	ID    string
+	Score float64
+}
+
+func SearchItems(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	if len(q) > maxSearchQueryLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q may be at most 200 characters"})
+		return
+	}
+
+	terms := searchTerms(q)
+	if len(terms) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q must contain letters or digits"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query, status, err := itemListQuery(c)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	var hits []searchHit
+	if database.SupportsFullTextSearch(database.DB) {
+		hits, err = searchItemsInDatabase(query, terms, limit)
+	} else {
+		hits, err = searchItemsInProcess(query, terms, limit)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search items"})
+		return
+	}
+
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+
+	var items []models.Item
+	if len(ids) > 0 {
+		if err := middleware.TenantDB(c).Unscoped().Preload("Tags").Where("id IN ?", ids).Find(&items).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch items"})
+			return
+		}
+	}
+	itemsByID := make(map[string]models.Item, len(items))
+	for _, item := range items {
+		itemsByID[item.ID] = item
+	}
+
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		item, ok := itemsByID[hit.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, SearchResult{
+			Item:      item,
+			Score:     hit.Score,
+			Highlight: highlightSearchMatches(item.Name, terms),
+		})
+	}
+
+	respondConditionalJSON(c, SearchResponse{Query: q, Data: results, Limit: limit}, latestItemUpdate(c))
+}
+
+// searchItemsInDatabase ranks full-text prefix matches and trigram
+// matches (for typos) using the indexes created by database.Migrate.
+func searchItemsInDatabase(query *gorm.DB, terms []string, limit int) ([]searchHit, error) {
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = term + ":*"
+	}
+	tsQuery := strings.Join(prefixes, " & ")
+	phrase := strings.Join(terms, " ")
+
+	var hits []searchHit
+	err := query.
+		Select("id, ts_rank(to_tsvector('simple', name), to_tsquery('simple', ?)) + word_similarity(?, name) AS score", tsQuery, phrase).
+		Where("(to_tsvector('simple', name) @@ to_tsquery('simple', ?) OR ? <% name)", tsQuery, phrase).
+		Order("score desc").
+		Order("id").
+		Limit(limit).
+		Scan(&hits).Error
+	return hits, err
+}
+
+// searchItemsInProcess is the fallback for databases without full-text
+// search, such as SQLite in tests. It scans the filtered items and scores
+// them with the same prefix and trigram rules.
+func searchItemsInProcess(query *gorm.DB, terms []string, limit int) ([]searchHit, error) {
+	rows, err := query.Select("id", "name").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []searchHit
+	for rows.Next() {
+		var item models.Item
+		if err := database.DB.ScanRows(rows, &item); err != nil {
+			return nil, err
+		}
+		if score, ok := scoreSearchMatch(item.Name, terms); ok {
+			hits = append(hits, searchHit{ID: item.ID, Score: score})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// scoreSearchMatch requires every term to match a word of the name and
+// averages how well they match.
+func scoreSearchMatch(name string, terms []string) (float64, bool) {
+	words := searchTerms(name)
+	total := 0.0
+	for _, term := range terms {
+		best := 0.0
+		for _, word := range words {
+			if score := matchSearchTerm(term, word); score > best {
+				best = score
+			}
+		}
+		if best == 0 {
+			return 0, false
+		}
+		total += best
+	}
+	return total / float64(len(terms)), true
+}
+
+func matchSearchTerm(term, word string) float64 {
+	switch {
+	case word == term:
+		return 1
+	case strings.HasPrefix(word, term):
+		return 0.9
+	}
+	if similarity := trigramSimilarity(term, word); similarity >= searchSimilarityThreshold {
+		return similarity * 0.8
+	}
+	return 0
+}
+
+// trigramSimilarity is the share of the term's trigrams that also occur in
+// the word, padded the way pg_trgm pads words.
+func trigramSimilarity(term, word string) float64 {
+	termTrigrams := trigrams(term)
+	if len(termTrigrams) == 0 {
+		return 0
+	}
+	wordTrigrams := trigrams(word)
+	shared := 0
+	for trigram := range termTrigrams {
+		if wordTrigrams[trigram] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(termTrigrams))
+}
+
+func trigrams(word string) map[string]bool {
+	runes := []rune("  " + word + " ")
+	result := make(map[string]bool, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		result[string(runes[i:i+3])] = true
+	}
+	return result
+}
+
+func searchTerms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words = uniqueStrings(words)
+	if len(words) > maxSearchTerms {
+		words = words[:maxSearchTerms]
+	}
+	return words
+}
+
+// highlightSearchMatches HTML-escapes the name and wraps every word that
+// matches a search term in <mark> tags.
+func highlightSearchMatches(name string, terms []string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i := 0; i < len(runes); {
+		j := i
+		isWord := unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])
+		for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) == isWord {
+			j++
+		}
+		segment := string(runes[i:j])
+		matched := false
+		if isWord {
+			lower := strings.ToLower(segment)
+			for _, term := range terms {
+				if matchSearchTerm(term, lower) > 0 {
+					matched = true
+					break
+				}
+			}
+		}
+		if matched {
+			b.WriteString("<mark>" + html.EscapeString(segment) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(segment))
+		}
+		i = j
+	}
+	return b.String()
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	formatCSV       = "csv"
+	formatXLSX      = "xlsx"
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+	// excelize keeps the whole archive in memory, so XLSX uploads get a
+	// lower cap than CSV. Unpacked worksheets over xlsxUnzipXMLSizeLimit
+	// are extracted to the temporary directory instead of memory, and
+	// archives that unpack to more than xlsxUnzipSizeLimit are refused.
+	maxXLSXImportFileSize = 10 << 20
+	xlsxUnzipXMLSizeLimit = 4 << 20
+	xlsxUnzipSizeLimit    = 512 << 20
+)
+
+var errXLSXTooLarge = errors.New("XLSX files may be at most 10 MB")
+
+// rowReader yields spreadsheet rows one at a time and returns io.EOF once
+// the input is exhausted.
+type rowReader interface {
+	Read() ([]string, error)
+	Close() error
+}
+
+type csvRowReader struct {
+	reader *csv.Reader
+}
+
+func (r *csvRowReader) Read() ([]string, error) {
+	return r.reader.Read()
+}
+
+func (r *csvRowReader) Close() error {
+	return nil
+}
+
+type xlsxRowReader struct {
+	file *excelize.File
+	rows *excelize.Rows
+}
+
+func (r *xlsxRowReader) Read() ([]string, error) {
+	if !r.rows.Next() {
+		if err := r.rows.Error(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return r.rows.Columns()
+}
+
+func (r *xlsxRowReader) Close() error {
+	r.rows.Close()
+	return r.file.Close()
+}
+
+// newRowReader reads CSV as a stream. XLSX files are zip archives and are
+// read into memory whole (up to maxXLSXImportFileSize) before the first
+// sheet is iterated row by row.
+func newRowReader(format string, r io.Reader) (rowReader, error) {
+	switch format {
+	case formatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return &csvRowReader{reader: reader}, nil
+	case formatXLSX:
+		file, err := excelize.OpenReader(&sizeLimitReader{r: r, remaining: maxXLSXImportFileSize}, excelize.Options{
+			UnzipSizeLimit:    xlsxUnzipSizeLimit,
+			UnzipXMLSizeLimit: xlsxUnzipXMLSizeLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		rows, err := file.Rows(file.GetSheetName(0))
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &xlsxRowReader{file: file, rows: rows}, nil
+	}
+	return nil, errUnsupportedFormat
+}
+
+// sizeLimitReader fails with errXLSXTooLarge once more than remaining
+// bytes have been read.
+type sizeLimitReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, errXLSXTooLarge
+	}
+	return n, err
+}
+
+// detectFormat picks the spreadsheet format from an explicit parameter,
+// then the file extension, then the content type.
+func detectFormat(param, filename, contentType string) string {
+	if param != "" {
+		return strings.ToLower(param)
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return formatCSV
+	case ".xlsx":
+		return formatXLSX
+	}
+	switch contentType {
+	case "text/csv", "application/csv":
+		return formatCSV
+	case xlsxContentType:
+		return formatXLSX
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"inventory_management/database"
+	"inventory_management/middleware"
+	"inventory_management/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	StockReasonOrder      = "order"
+	StockReasonReceipt    = "receipt"
+	StockReasonAdjustment = "adjustment"
+)
+
+type StockAdjustmentRequest struct {
+	Delta  int    `json:"delta" binding:"required"`
+	Reason string `json:"reason" binding:"required,oneof=order receipt adjustment"`
+}
+
+func AdjustStock(c *gin.Context) {
+	id := c.Param("id")
+
+	var req StockAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Reason == StockReasonOrder && req.Delta > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Orders must decrease stock"})
+		return
+	}
+	if req.Reason == StockReasonReceipt && req.Delta < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Receipts must increase stock"})
+		return
+	}
+
+	var item models.Item
+	result := middleware.TenantDB(c).Preload("Tags").First(&item, "id = ?", id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	if req.Reason == StockReasonOrder && !item.Status.AcceptsOrders() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Item is " + string(item.Status) + " and cannot accept new orders"})
+		return
+	}
+	if req.Delta < 0 && !item.Status.AllowsStockDecrement() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Stock cannot be decreased for " + string(item.Status) + " items"})
+		return
+	}
+	if req.Delta > 0 && !item.Status.AllowsStockIncrement() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Stock cannot be increased for " + string(item.Status) + " items"})
+		return
+	}
+
+	result = middleware.TenantDB(c).Model(&models.Item{}).
+		Where("id = ? AND status = ? AND stock + ? >= 0", id, item.Status, req.Delta).
+		Updates(map[string]interface{}{
+			"stock":   gorm.Expr("stock + ?", req.Delta),
+			"version": gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to adjust stock"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Insufficient stock or item changed concurrently"})
+		return
+	}
+
+	before := item
+	if err := middleware.TenantDB(c).Preload("Tags").First(&item, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	database.SetItemToCache(middleware.CurrentTenant(c), id, item)
+	middleware.RecordAudit(c, "adjust_stock", "item", id, before, item)
+	setItemETag(c, item)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Stock adjusted successfully",
+		"data":    item,
+	})
+}
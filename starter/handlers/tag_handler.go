@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"errors"
+	"inventory_management/database"
+	"inventory_management/middleware"
+	"inventory_management/models"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const maxTagsPerRequest = 100
+
+type TagsRequest struct {
+	Tags []string `json:"tags" binding:"required,min=1"`
+}
+
+type BulkTagsRequest struct {
+	ItemIDs []string `json:"item_ids" binding:"required,min=1"`
+	Add     []string `json:"add"`
+	Remove  []string `json:"remove"`
+}
+
+var errTaggedItemNotFound = errors.New("item not found")
+
+func GetAllTags(c *gin.Context) {
+	var tags []models.Tag
+	if err := middleware.TenantDB(c).Order("name asc").Find(&tags).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tags})
+}
+
+func AddItemTags(c *gin.Context) {
+	updateItemTags(c, true)
+}
+
+func RemoveItemTags(c *gin.Context) {
+	updateItemTags(c, false)
+}
+
+func updateItemTags(c *gin.Context, add bool) {
+	id := c.Param("id")
+
+	var req TagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	names, err := normalizeTagNames(req.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var before models.Item
+	if err := middleware.TenantDB(c).Preload("Tags").First(&before, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	var addNames, removeNames []string
+	if add {
+		addNames = names
+	} else {
+		removeNames = names
+	}
+
+	err = middleware.TenantDB(c).Transaction(func(tx *gorm.DB) error {
+		return applyTagChanges(tx, []string{id}, addNames, removeNames)
+	})
+	if err != nil {
+		if err == errTaggedItemNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tags"})
+		}
+		return
+	}
+
+	database.DeleteItemFromCache(middleware.CurrentTenant(c), id)
+
+	var item models.Item
+	if err := middleware.TenantDB(c).Preload("Tags").First(&item, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	middleware.RecordAudit(c, "update_tags", "item", id, before, item)
+	setItemETag(c, item)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tags updated successfully",
+		"data":    item,
+	})
+}
+
+func BulkUpdateTags(c *gin.Context) {
+	var req BulkTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one tag to add or remove is required"})
+		return
+	}
+
+	addNames, err := normalizeTagNames(req.Add)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	removeNames, err := normalizeTagNames(req.Remove)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var before []models.Item
+	if err := middleware.TenantDB(c).Preload("Tags").Where("id IN ?", req.ItemIDs).Find(&before).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	err = middleware.TenantDB(c).Transaction(func(tx *gorm.DB) error {
+		return applyTagChanges(tx, req.ItemIDs, addNames, removeNames)
+	})
+	if err != nil {
+		if err == errTaggedItemNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "One or more items not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tags"})
+		}
+		return
+	}
+
+	for _, id := range req.ItemIDs {
+		database.DeleteItemFromCache(middleware.CurrentTenant(c), id)
+	}
+
+	var after []models.Item
+	middleware.TenantDB(c).Preload("Tags").Where("id IN ?", req.ItemIDs).Find(&after)
+	afterByID := make(map[string]models.Item, len(after))
+	for _, item := range after {
+		afterByID[item.ID] = item
+	}
+	for _, item := range before {
+		middleware.RecordAudit(c, "update_tags", "item", item.ID, item, afterByID[item.ID])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tags updated successfully",
+		"updated": len(req.ItemIDs),
+	})
+}
+
+func applyTagChanges(tx *gorm.DB, itemIDs []string, add, remove []string) error {
+	var items []models.Item
+	if err := tx.Where("id IN ?", itemIDs).Find(&items).Error; err != nil {
+		return err
+	}
+	if len(items) != len(uniqueStrings(itemIDs)) {
+		return errTaggedItemNotFound
+	}
+
+	addTags, err := findOrCreateTags(tx, add)
+	if err != nil {
+		return err
+	}
+
+	var removeTags []models.Tag
+	if len(remove) > 0 {
+		if err := tx.Where("name IN ?", remove).Find(&removeTags).Error; err != nil {
+			return err
+		}
+	}
+
+	for i := range items {
+		if len(addTags) > 0 {
+			if err := tx.Model(&items[i]).Association("Tags").Append(addTags); err != nil {
+				return err
+			}
+		}
+		if len(removeTags) > 0 {
+			if err := tx.Model(&items[i]).Association("Tags").Delete(removeTags); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Model(&models.Item{}).
+		Where("id IN ?", itemIDs).
+		Update("version", gorm.Expr("version + 1")).Error
+}
+
+func findOrCreateTags(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	var tags []models.Tag
+	if err := tx.Where("name IN ?", names).Find(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		existing[tag.Name] = true
+	}
+
+	for _, name := range names {
+		if existing[name] {
+			continue
+		}
+		tag := models.Tag{ID: uuid.New().String(), Name: name}
+		if err := tx.Create(&tag).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+func normalizeTagNames(raw []string) ([]string, error) {
+	if len(raw) > maxTagsPerRequest {
+		return nil, errors.New("Too many tags in a single request")
+	}
+
+	names := make([]string, 0, len(raw))
+	for _, r := range raw {
+		name := models.NormalizeTagName(r)
+		if name == "" || len(name) > 50 {
+			return nil, errors.New("Tag names must be between 1 and 50 characters")
+		}
+		names = append(names, name)
+	}
+	return uniqueStrings(names), nil
+}
+
+func parseTagFilter(param string) []string {
+	var names []string
+	for _, part := range strings.Split(param, ",") {
+		if name := models.NormalizeTagName(part); name != "" {
+			names = append(names, name)
+		}
+	}
+	return uniqueStrings(names)
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+	"inventory_management/database"
+	"inventory_management/middleware"
+	"inventory_management/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CreateUserRequest struct {
+	Username string      `json:"username" binding:"required,min=3,max=50"`
+	Password string      `json:"password" binding:"required"`
+	Role     models.Role `json:"role"`
+	// TenantID creates the user in another tenant; only admins of the
+	// default tenant may do so.
+	TenantID string `json:"tenant_id"`
+}
+
+type SetRoleRequest struct {
+	Role models.Role `json:"role" binding:"required"`
+}
+
+var errInvalidRole = errors.New("role must be one of viewer, clerk, manager, admin")
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+func GetUsers(c *gin.Context) {
+	var users []models.User
+	if err := middleware.TenantDB(c).Order("username asc").Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": users})
+}
+
+func CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = models.RoleViewer
+	}
+	if !req.Role.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidRole.Error()})
+		return
+	}
+	tenant := middleware.CurrentTenant(c)
+	if req.TenantID != "" && req.TenantID != tenant {
+		if tenant != database.DefaultTenant {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the default tenant can create users in other tenants"})
+			return
+		}
+		if !database.ValidTenantID(req.TenantID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tenant_id must be lowercase letters, digits, '-' or '_'"})
+			return
+		}
+		tenant = req.TenantID
+	}
+
+	// Usernames are unique across tenants since logins do not name one.
+	var count int64
+	if err := database.DB.Model(&models.User{}).Where("username = ?", req.Username).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Username is already taken"})
+		return
+	}
+
+	user := models.User{ID: uuid.New().String(), Username: req.Username, Role: req.Role}
+	if err := user.SetPassword(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.ForTenant(tenant).Create(&user).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
+	}
+
+	middleware.RecordAudit(c, "create", "user", user.ID, nil, user)
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "User created successfully",
+		"data":    user,
+	})
+}
+
+func DisableUser(c *gin.Context) {
+	setUserDisabled(c, true)
+}
+
+func EnableUser(c *gin.Context) {
+	setUserDisabled(c, false)
+}
+
+func setUserDisabled(c *gin.Context, disabled bool) {
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+	if disabled && user.Username == middleware.CurrentUsername(c) {
+		c.JSON(http.StatusConflict, gin.H{"error": "You cannot disable your own account"})
+		return
+	}
+
+	before := user
+	if err := middleware.TenantDB(c).Model(&user).Update("disabled", disabled).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+	if disabled {
+		if err := database.RevokeUserRefreshTokens(database.DB, user.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+			return
+		}
+	}
+
+	action := "enable"
+	if disabled {
+		action = "disable"
+	}
+	middleware.RecordAudit(c, action, "user", user.ID, before, user)
+	c.JSON(http.StatusOK, gin.H{"data": user})
+}
+
+// SetUserRole changes a user's role. Tokens issued with the old role stop
+// working, so the user has to log in again.
+func SetUserRole(c *gin.Context) {
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	var req SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Role.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidRole.Error()})
+		return
+	}
+	if user.Username == middleware.CurrentUsername(c) {
+		c.JSON(http.StatusConflict, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+
+	before := user
+	if err := middleware.TenantDB(c).Model(&user).Update("role", req.Role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+
+	middleware.RecordAudit(c, "set_role", "user", user.ID, before, user)
+	c.JSON(http.StatusOK, gin.H{"data": user})
+}
+
+// ChangePassword lets users change their own password, which requires the
+// current one. Resetting someone else's needs users:manage.
+func ChangePassword(c *gin.Context) {
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	self := user.Username == middleware.CurrentUsername(c)
+	if !self && !middleware.HasPermission(c, middleware.PermUsersManage) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Missing permission: " + string(middleware.PermUsersManage)})
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if self && !user.CheckPassword(req.CurrentPassword) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+
+	if err := user.SetPassword(req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := middleware.TenantDB(c).Model(&user).Select("password_hash", "password_changed_at").Updates(&user).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		return
+	}
+	if err := database.RevokeUserRefreshTokens(database.DB, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	middleware.RecordAudit(c, "change_password", "user", user.ID, nil, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+}
+
+func findUser(c *gin.Context) (models.User, bool) {
+	var user models.User
+	if err := middleware.TenantDB(c).First(&user, "id = ?", c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return user, false
+	}
+	return user, true
+}
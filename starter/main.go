@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"inventory_management/database"
+	"inventory_management/middleware"
 	"inventory_management/routes"
 )
 
@@ -12,6 +13,13 @@ func main() {
 	database.InitRedis()
 	database.InitDatabase()
 	defer database.CloseDatabase()
+	database.StartPurgeJob()
+
+	// InitDatabase loads .env, which may configure the signing keys.
+	if err := middleware.InitSigningKeys(); err != nil {
+		log.Fatal("Failed to load JWT signing keys:", err)
+	}
+	middleware.StartKeyRotation()
 
 	log.Println("Server successfully connected to the database and seeded data.")
 	router := routes.SetupRoutes()
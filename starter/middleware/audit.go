@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"inventory_management/database"
+	"inventory_management/models"
+)
+
+const (
+	auditChangesKey = "audit_changes"
+	auditActorKey   = "audit_actor"
+)
+
+type auditChange struct {
+	action     string
+	resource   string
+	resourceID string
+	before     interface{}
+	after      interface{}
+}
+
+// RecordAudit attaches a change to the current request; AuditMiddleware
+// persists it together with the request metadata once the handler returns.
+func RecordAudit(c *gin.Context, action, resource, resourceID string, before, after interface{}) {
+	var changes []auditChange
+	if existing, ok := c.Get(auditChangesKey); ok {
+		changes = existing.([]auditChange)
+	}
+	c.Set(auditChangesKey, append(changes, auditChange{
+		action:     action,
+		resource:   resource,
+		resourceID: resourceID,
+		before:     before,
+		after:      after,
+	}))
+}
+
+// SetAuditActor names the actor for requests that are not authenticated
+// with a token, such as login attempts.
+func SetAuditActor(c *gin.Context, actor string) {
+	c.Set(auditActorKey, actor)
+}
+
+func AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			return
+		}
+		if database.DB == nil {
+			return
+		}
+
+		actor := CurrentUsername(c)
+		if actor == "" {
+			actor = c.GetString(auditActorKey)
+		}
+
+		var changes []auditChange
+		if existing, ok := c.Get(auditChangesKey); ok {
+			changes = existing.([]auditChange)
+		}
+		if len(changes) == 0 {
+			resource := c.FullPath()
+			if resource == "" {
+				resource = c.Request.URL.Path
+			}
+			changes = []auditChange{{
+				action:     strings.ToLower(c.Request.Method),
+				resource:   resource,
+				resourceID: c.Param("id"),
+			}}
+		}
+
+		entries := make([]models.AuditLog, 0, len(changes))
+		for _, change := range changes {
+			before := toAuditJSON(change.before)
+			after := toAuditJSON(change.after)
+			entries = append(entries, models.AuditLog{
+				ID:         uuid.New().String(),
+				TenantID:   CurrentTenant(c),
+				Actor:      actor,
+				Action:     change.action,
+				Resource:   change.resource,
+				ResourceID: change.resourceID,
+				Method:     c.Request.Method,
+				Path:       c.Request.URL.Path,
+				StatusCode: c.Writer.Status(),
+				Before:     before,
+				After:      after,
+				Diff:       diffAuditJSON(before, after),
+				RequestID:  RequestID(c),
+				ClientIP:   c.ClientIP(),
+			})
+		}
+
+		if err := database.DB.CreateInBatches(&entries, 500).Error; err != nil {
+			log.Println("[Audit] Failed to write audit log:", err)
+		}
+	}
+}
+
+func toAuditJSON(v interface{}) models.JSON {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return models.JSON(b)
+}
+
+func diffAuditJSON(before, after models.JSON) models.JSON {
+	var beforeFields, afterFields map[string]interface{}
+	_ = json.Unmarshal(before, &beforeFields)
+	_ = json.Unmarshal(after, &afterFields)
+	if beforeFields == nil && afterFields == nil {
+		return nil
+	}
+
+	diff := make(map[string]interface{})
+	for key, from := range beforeFields {
+		to, ok := afterFields[key]
+		if !ok || !reflect.DeepEqual(from, to) {
+			diff[key] = gin.H{"from": from, "to": to}
+		}
+	}
+	for key, to := range afterFields {
+		if _, ok := beforeFields[key]; !ok {
+			diff[key] = gin.H{"from": nil, "to": to}
+		}
+	}
+	return toAuditJSON(diff)
+}
@@ -1,59 +1,302 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
-	"os"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"inventory_management/database"
+	"inventory_management/models"
 )
 
-var jwtSecret []byte
-var jwtSecretOnce sync.Once
+const (
+	usernameKey       = "username"
+	tokenIDKey        = "token_id"
+	tokenExpiresAtKey = "token_expires_at"
+	apiKeyIDKey       = "api_key_id"
+	apiKeyHeader      = "X-API-Key"
 
-func getJWTSecret() []byte {
-	jwtSecretOnce.Do(func() {
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			secret = "your_secret_key"
-		}
-		jwtSecret = []byte(secret)
-	})
-	return jwtSecret
+	defaultAccessTokenTTL = 15 * time.Minute
+)
+
+// AccessTokenTTL is how long access tokens are valid (ACCESS_TOKEN_TTL,
+// default 15m); clients renew them with a refresh token.
+func AccessTokenTTL() time.Duration {
+	return database.DurationFromEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+}
+
+// GenerateJWT issues an access token limited to scopes, or to every scope
+// the role can use when none are given. Scopes the role gains nothing from
+// are dropped.
+func GenerateJWT(username string, role models.Role, scopes ...string) (string, error) {
+	return signClaims(accessTokenClaims(username, role, scopes))
+}
+
+// GenerateStepUpJWT issues an access token like GenerateJWT for a login
+// that also passed a second factor. Its amr claim says so.
+func GenerateStepUpJWT(username string, role models.Role, scopes ...string) (string, error) {
+	claims := accessTokenClaims(username, role, scopes)
+	claims["amr"] = []string{"pwd", "otp"}
+	return signClaims(claims)
 }
 
-func GenerateJWT(username string) (string, error) {
-	claims := jwt.MapClaims{
+func accessTokenClaims(username string, role models.Role, scopes []string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"jti":      uuid.New().String(),
 		"username": username,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(),
+		"role":     string(role),
+		"scope":    strings.Join(GrantedScopes(role, scopes), " "),
+		"iat":      now.Unix(),
+		"exp":      now.Add(AccessTokenTTL()).Unix(),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(getJWTSecret())
 }
 
+func signClaims(claims jwt.MapClaims) (string, error) {
+	set, err := signingKeys()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(set.signing.method, claims)
+	if set.signing.kid != "" {
+		token.Header["kid"] = set.signing.kid
+	}
+	return token.SignedString(set.signing.private)
+}
+
+var (
+	errAuthHeaderInvalid = errors.New("Authorization header missing or invalid")
+	errTokenInvalid      = errors.New("Invalid or expired token")
+	errAccountInactive   = errors.New("Account is disabled or no longer exists")
+	errTokenRevoked      = errors.New("Token has been revoked")
+)
+
+// JWTAuthMiddleware accepts a bearer token or, for machine clients, an
+// X-API-Key header. Keys are granted their scopes instead of a role. When
+// REQUIRE_MFA_FOR_ADMINS is "true", admin tokens are only accepted for
+// writes if their login passed a second factor.
 func JWTAuthMiddleware() gin.HandlerFunc {
+	return jwtAuth(true)
+}
+
+// MFAEnrollmentAuthMiddleware is JWTAuthMiddleware without the MFA policy,
+// for what an admin has to do before they have a second factor: enrol one
+// or log out.
+func MFAEnrollmentAuthMiddleware() gin.HandlerFunc {
+	return jwtAuth(false)
+}
+
+func jwtAuth(enforceMFA bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header missing or invalid"})
+		if !authorizeRequest(c) || (enforceMFA && !checkMFAPolicy(c)) {
 			c.Abort()
 			return
 		}
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
+		c.Next()
+	}
+}
+
+// authorizeRequest authenticates the request and stores who made it, for
+// which tenant and what they may do in the context. It responds with 401
+// and returns false when the credentials are missing or invalid.
+func authorizeRequest(c *gin.Context) bool {
+	if raw := c.GetHeader(apiKeyHeader); raw != "" {
+		key, err := database.FindAPIKey(database.DB, raw)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": database.ErrAPIKeyInvalid.Error()})
+			return false
+		}
+		if !setAuthenticatedTenant(c, key.TenantID) {
+			return false
+		}
+		c.Set(apiKeyIDKey, key.ID)
+		c.Set(permissionsKey, apiKeyPermissions(key.Scopes))
+		SetAuditActor(c, "api_key:"+key.Prefix)
+		return true
+	}
+
+	p, err := authenticate(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return false
+	}
+	if !setAuthenticatedTenant(c, p.user.TenantID) {
+		return false
+	}
+	c.Set(usernameKey, p.user.Username)
+	c.Set(roleKey, p.user.Role)
+	c.Set(permissionsKey, effectivePermissions(p.user.Role, p.scopes))
+	c.Set(tokenIDKey, p.tokenID)
+	c.Set(mfaKey, p.mfa)
+	if !p.expiresAt.IsZero() {
+		c.Set(tokenExpiresAtKey, p.expiresAt)
+	}
+	return true
+}
+
+func CurrentUsername(c *gin.Context) string {
+	return c.GetString(usernameKey)
+}
+
+// IsAuthenticated reports whether JWTAuthMiddleware or RequireReadAccess
+// accepted credentials for this request.
+func IsAuthenticated(c *gin.Context) bool {
+	_, ok := c.Get(permissionsKey)
+	return ok
+}
+
+// apiKeyPermissions grants a key its scopes. A token scope such as
+// inventory:write means the same for a key as for a token; other scopes
+// are single permissions.
+func apiKeyPermissions(scopes []string) []Permission {
+	permissions := make([]Permission, 0, len(scopes))
+	for _, scope := range scopes {
+		if !IsAPIKeyScope(scope) {
+			continue
+		}
+		granted, ok := scopePermissions[scope]
+		if !ok {
+			granted = []Permission{Permission(scope)}
+		}
+		for _, permission := range granted {
+			if IsAPIKeyScope(string(permission)) {
+				permissions = append(permissions, permission)
 			}
-			return getJWTSecret(), nil
-		})
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
-			return
 		}
-		c.Next()
 	}
+	return permissions
+}
+
+// HasSession reports whether the request was made with an access token
+// issued by this service, i.e. one that RevokeCurrentToken can revoke.
+// API keys and identity provider tokens are not sessions of ours.
+func HasSession(c *gin.Context) bool {
+	_, ok := c.Get(tokenExpiresAtKey)
+	return ok
+}
+
+// RevokeCurrentToken puts the access token of the request on the
+// revocation list, e.g. on logout.
+func RevokeCurrentToken(c *gin.Context) {
+	expiresAt, _ := c.Get(tokenExpiresAtKey)
+	if t, ok := expiresAt.(time.Time); ok {
+		database.RevokeToken(c.GetString(tokenIDKey), t)
+	}
+}
+
+// principal is who a bearer token speaks for and what it may do, and
+// whether they passed a second factor. Identity provider tokens have no
+// ID or expiry of ours.
+type principal struct {
+	user      models.User
+	scopes    []string
+	mfa       bool
+	tokenID   string
+	expiresAt time.Time
+}
+
+// authenticate checks the bearer token, that it has not been revoked and
+// that its user still exists, is enabled and has not changed password or
+// role since the token was issued. Tokens from the OIDC identity provider
+// are checked by authenticateOIDC.
+func authenticate(c *gin.Context) (principal, error) {
+	var p principal
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		return p, errAuthHeaderInvalid
+	}
+	raw := strings.TrimPrefix(authHeader, "Bearer ")
+	if isOIDCToken(raw) {
+		return authenticateOIDC(c, raw)
+	}
+
+	token, err := parseToken(raw)
+	if err != nil {
+		return p, errTokenInvalid
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return p, errTokenInvalid
+	}
+	username, _ := claims["username"].(string)
+	jti, _ := claims["jti"].(string)
+	// MFA challenges are signed with the same keys but are no access tokens.
+	if _, ok := claims["purpose"]; ok || username == "" || jti == "" {
+		return p, errTokenInvalid
+	}
+	if database.IsTokenRevoked(jti) {
+		return p, errTokenRevoked
+	}
+
+	if err := database.DB.Where("username = ?", username).First(&p.user).Error; err != nil || p.user.Disabled {
+		return p, errAccountInactive
+	}
+	issuedAt, err := claims.GetIssuedAt()
+	if err != nil || issuedAt == nil || issuedAt.Unix() < p.user.PasswordChangedAt.Unix() {
+		return p, errTokenInvalid
+	}
+	if role, _ := claims["role"].(string); models.Role(role) != p.user.Role {
+		return p, errTokenInvalid
+	}
+
+	// Tokens issued before scopes existed carry no scope claim.
+	p.scopes = Scopes
+	if scope, ok := claims["scope"].(string); ok {
+		p.scopes = strings.Fields(scope)
+	}
+	p.mfa = containsString(stringsClaim(claims["amr"]), "otp")
+	p.tokenID = jti
+	if expiresAt, err := claims.GetExpirationTime(); err == nil && expiresAt != nil {
+		p.expiresAt = expiresAt.Time
+	}
+	return p, nil
+}
+
+// authenticateOIDC accepts an access token from the identity provider and
+// maps it to the linked local account, creating it on first use.
+func authenticateOIDC(c *gin.Context, raw string) (principal, error) {
+	identity, err := verifyOIDCAccessToken(c.Request.Context(), raw)
+	if err != nil {
+		return principal{}, errTokenInvalid
+	}
+	user, err := database.UpsertExternalUser(database.DB, identity.ExternalID, identity.Username, identity.Role, identity.Tenant)
+	if err != nil || user.Disabled {
+		return principal{}, errAccountInactive
+	}
+	return principal{user: user, scopes: identity.Scopes, mfa: identity.MFA}, nil
+}
+
+// parseToken verifies tokenString with the key named by its kid header,
+// which must also match the token's algorithm. A kid newer than the
+// loaded keys makes it reload them once.
+func parseToken(tokenString string) (*jwt.Token, error) {
+	set, err := signingKeys()
+	if err != nil {
+		return nil, err
+	}
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := set.verify[kid]
+		if !ok {
+			key, ok = reloadForKid(set, kid)
+		}
+		if !ok || key.method.Alg() != token.Method.Alg() {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+		return key.public, nil
+	}, jwt.WithValidMethods([]string{
+		jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg(),
+	}))
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrTokenUnverifiable
+	}
+	return token, nil
 }
@@ -0,0 +1,425 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"inventory_management/database"
+)
+
+const (
+	defaultKeysDir             = "keys"
+	defaultKeyRotationInterval = 30 * 24 * time.Hour
+	defaultKeyOverlap          = 24 * time.Hour
+	keyRotationCheckInterval   = time.Hour
+	rsaKeyBits                 = 2048
+	kidTimeLayout              = "20060102T150405Z"
+)
+
+// kidPattern is what a kid must look like to be looked up in JWT_KEYS_DIR.
+var kidPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// signingKey is one entry of the key set. HS256 keys have no kid; RS256
+// and EdDSA keys are named after their PEM file in JWT_KEYS_DIR, and the
+// kid starts with their creation time (see keyCreatedAt).
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	private   interface{}
+	public    interface{}
+	createdAt time.Time
+}
+
+// keySet is the key that signs and the keys that verify. newest is when
+// the newest key was created; at refreshAt the choice of signing key
+// changes and the set is rebuilt.
+type keySet struct {
+	signing   *signingKey
+	verify    map[string]*signingKey
+	newest    time.Time
+	refreshAt time.Time
+}
+
+// JSONWebKey is the public half of a signing key as published in the JWKS.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+var (
+	keysMu      sync.RWMutex
+	currentKeys *keySet
+	keysOnce    sync.Once
+	keysErr     error
+
+	generatedSecret     []byte
+	generatedSecretOnce sync.Once
+)
+
+// InitSigningKeys loads the key set for JWT_SIGNING_ALG (HS256, RS256 or
+// EdDSA). It runs on first use, so calling it at startup only serves to
+// surface configuration errors early.
+func InitSigningKeys() error {
+	keysOnce.Do(func() {
+		keysErr = ReloadSigningKeys()
+	})
+	return keysErr
+}
+
+func signingKeys() (*keySet, error) {
+	if err := InitSigningKeys(); err != nil {
+		return nil, err
+	}
+	keysMu.RLock()
+	set := currentKeys
+	keysMu.RUnlock()
+	if set.refreshAt.IsZero() || time.Now().Before(set.refreshAt) {
+		return set, nil
+	}
+	if err := ReloadSigningKeys(); err != nil {
+		log.Println("[Auth] Failed to reload signing keys:", err)
+		return set, nil
+	}
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	return currentKeys, nil
+}
+
+// ReloadSigningKeys rereads the configuration and JWT_KEYS_DIR, picking up
+// keys added by operators or other instances and dropping retired ones.
+func ReloadSigningKeys() error {
+	set, err := loadKeySet()
+	if err != nil {
+		return err
+	}
+	keysMu.Lock()
+	currentKeys = set
+	keysMu.Unlock()
+	return nil
+}
+
+func signingAlgorithm() string {
+	alg := os.Getenv("JWT_SIGNING_ALG")
+	if alg == "" {
+		return jwt.SigningMethodHS256.Alg()
+	}
+	return alg
+}
+
+func loadKeySet() (*keySet, error) {
+	alg := signingAlgorithm()
+	switch alg {
+	case jwt.SigningMethodHS256.Alg():
+		key := &signingKey{method: jwt.SigningMethodHS256, private: hmacSecret()}
+		key.public = key.private
+		return &keySet{signing: key, verify: map[string]*signingKey{"": key}}, nil
+	case jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg():
+	default:
+		return nil, fmt.Errorf("unsupported JWT_SIGNING_ALG %q", alg)
+	}
+
+	dir := keysDir()
+	keys, err := loadKeyDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !hasKeyFor(keys, alg) {
+		key, err := generateKeyFile(dir, alg)
+		if err != nil {
+			return nil, err
+		}
+		keys = append([]*signingKey{key}, keys...)
+	}
+	return buildKeySet(keys, alg, database.DurationFromEnv("JWT_KEY_OVERLAP", defaultKeyOverlap), time.Now()), nil
+}
+
+// buildKeySet publishes a new key for overlap before signing with it, so
+// that JWKS caches and other instances know it before the first token
+// signed with it arrives. Signing uses the newest key of alg published for
+// that long, or the oldest one when none is, such as the very first key.
+// The key it replaced keeps verifying for another overlap, so tokens
+// signed just before the switch stay valid.
+func buildKeySet(keys []*signingKey, alg string, overlap time.Duration, now time.Time) *keySet {
+	sort.Slice(keys, func(i, j int) bool { return keys[i].createdAt.After(keys[j].createdAt) })
+
+	set := &keySet{verify: make(map[string]*signingKey)}
+	if len(keys) > 0 {
+		set.newest = keys[0].createdAt
+	}
+	for _, key := range keys {
+		if key.method.Alg() != alg {
+			continue
+		}
+		if activatesAt := key.createdAt.Add(overlap); now.Before(activatesAt) {
+			set.refreshAt = activatesAt
+		} else {
+			set.signing = key
+			break
+		}
+	}
+	if set.signing == nil {
+		for _, key := range keys {
+			if key.method.Alg() == alg {
+				set.signing = key
+			}
+		}
+	}
+
+	var successor *signingKey
+	for _, key := range keys {
+		if successor == nil || !key.createdAt.Before(set.signing.createdAt) || now.Sub(successor.createdAt) < 2*overlap {
+			set.verify[key.kid] = key
+		}
+		successor = key
+	}
+	return set
+}
+
+func hasKeyFor(keys []*signingKey, alg string) bool {
+	for _, key := range keys {
+		if key.method.Alg() == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// hmacSecret returns JWT_SECRET, or a random secret when it is unset; the
+// random one lasts for the life of the process.
+func hmacSecret() []byte {
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	generatedSecretOnce.Do(func() {
+		generatedSecret = make([]byte, 32)
+		if _, err := rand.Read(generatedSecret); err != nil {
+			panic(err)
+		}
+		log.Println("[Auth] JWT_SECRET is not set; using a random secret, tokens will not survive a restart")
+	})
+	return generatedSecret
+}
+
+func keysDir() string {
+	if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+		return dir
+	}
+	return defaultKeysDir
+}
+
+func loadKeyDir(dir string) ([]*signingKey, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*signingKey
+	for _, path := range paths {
+		key, err := loadKeyFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load signing key %s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func loadKeyFile(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		err = fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+	key := &signingKey{kid: kid, private: parsed, createdAt: keyCreatedAt(kid)}
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.public = jwt.SigningMethodRS256, &private.PublicKey
+	case ed25519.PrivateKey:
+		key.method, key.public = jwt.SigningMethodEdDSA, private.Public()
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+	return key, nil
+}
+
+func generateKeyFile(dir, alg string) (*signingKey, error) {
+	var private crypto.Signer
+	var err error
+	if alg == jwt.SigningMethodRS256.Alg() {
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	} else {
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	kid := time.Now().UTC().Format(kidTimeLayout) + "-" + hex.EncodeToString(suffix)
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, kid+".pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return nil, err
+	}
+	log.Printf("[Auth] Generated %s signing key %s", alg, kid)
+	return loadKeyFile(path)
+}
+
+// keyCreatedAt reads the creation time from a kid such as
+// 20240101T000000Z-1a2b3c4d rather than from the file, which copies and
+// restores would change. Keys named otherwise count as the oldest.
+func keyCreatedAt(kid string) time.Time {
+	stamp, _, _ := strings.Cut(kid, "-")
+	createdAt, err := time.Parse(kidTimeLayout, stamp)
+	if err != nil {
+		return time.Time{}
+	}
+	return createdAt
+}
+
+// reloadForKid reloads the key set when kid names a key newer than any
+// loaded so far that exists in JWT_KEYS_DIR, i.e. one another instance
+// has just generated. Unknown older kids never cause a reload.
+func reloadForKid(set *keySet, kid string) (*signingKey, bool) {
+	if !kidPattern.MatchString(kid) || !keyCreatedAt(kid).After(set.newest) {
+		return nil, false
+	}
+	if _, err := os.Stat(filepath.Join(keysDir(), kid+".pem")); err != nil {
+		return nil, false
+	}
+	if err := ReloadSigningKeys(); err != nil {
+		log.Println("[Auth] Failed to reload signing keys:", err)
+		return nil, false
+	}
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	key, ok := currentKeys.verify[kid]
+	return key, ok
+}
+
+// RotateSigningKey generates a new key for JWT_SIGNING_ALG. It is
+// published at once and signs after JWT_KEY_OVERLAP; the previous key
+// keeps verifying for another JWT_KEY_OVERLAP after that.
+func RotateSigningKey() error {
+	alg := signingAlgorithm()
+	if alg == jwt.SigningMethodHS256.Alg() {
+		return errors.New("HS256 keys cannot be rotated; change JWT_SECRET instead")
+	}
+	if _, err := generateKeyFile(keysDir(), alg); err != nil {
+		return err
+	}
+	return ReloadSigningKeys()
+}
+
+// StartKeyRotation reloads the key set every hour and rotates once the
+// newest key, published or signing, is older than
+// JWT_KEY_ROTATION_INTERVAL (default 720h). Instances sharing
+// JWT_KEYS_DIR see each other's new keys, so normally only the first to
+// notice rotates. It does nothing for HS256.
+func StartKeyRotation() {
+	if signingAlgorithm() == jwt.SigningMethodHS256.Alg() {
+		return
+	}
+	interval := database.DurationFromEnv("JWT_KEY_ROTATION_INTERVAL", defaultKeyRotationInterval)
+
+	go func() {
+		for {
+			time.Sleep(keyRotationCheckInterval)
+			if err := ReloadSigningKeys(); err != nil {
+				log.Println("[Auth] Failed to reload signing keys:", err)
+				continue
+			}
+			set, _ := signingKeys()
+			if time.Since(set.newest) < interval {
+				continue
+			}
+			if err := RotateSigningKey(); err != nil {
+				log.Println("[Auth] Failed to rotate signing key:", err)
+			}
+		}
+	}()
+}
+
+// JWKS returns the public keys that currently verify tokens, newest first.
+func JWKS() ([]JSONWebKey, error) {
+	set, err := signingKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]JSONWebKey, 0, len(set.verify))
+	for _, key := range set.verify {
+		jwk := JSONWebKey{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			// HMAC secrets are never published.
+			continue
+		}
+		keys = append(keys, jwk)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return set.verify[keys[i].Kid].createdAt.After(set.verify[keys[j].Kid].createdAt)
+	})
+	return keys, nil
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"inventory_management/database"
+	"inventory_management/models"
+)
+
+const (
+	mfaKey              = "mfa"
+	mfaChallengePurpose = "mfa"
+
+	defaultMFAChallengeTTL = 5 * time.Minute
+)
+
+var errMFAChallengeInvalid = errors.New("Invalid or expired MFA token")
+
+// MFAChallenge is handed out instead of tokens when a user with two-factor
+// authentication logs in with the right password. It is exchanged, once,
+// for tokens together with a TOTP or recovery code.
+type MFAChallenge struct {
+	ID        string
+	Username  string
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+// MFAChallengeTTL is how long a user has to enter their code
+// (MFA_CHALLENGE_TTL, default 5m).
+func MFAChallengeTTL() time.Duration {
+	return database.DurationFromEnv("MFA_CHALLENGE_TTL", defaultMFAChallengeTTL)
+}
+
+// GenerateMFAChallenge signs a challenge for username that remembers the
+// scopes requested at login.
+func GenerateMFAChallenge(username string, scopes []string) (string, error) {
+	now := time.Now()
+	return signClaims(jwt.MapClaims{
+		"jti":      uuid.New().String(),
+		"username": username,
+		"purpose":  mfaChallengePurpose,
+		"scope":    strings.Join(scopes, " "),
+		"iat":      now.Unix(),
+		"exp":      now.Add(MFAChallengeTTL()).Unix(),
+	})
+}
+
+// ParseMFAChallenge verifies a challenge from GenerateMFAChallenge that
+// has not been used yet.
+func ParseMFAChallenge(raw string) (MFAChallenge, error) {
+	var challenge MFAChallenge
+	token, err := parseToken(raw)
+	if err != nil {
+		return challenge, errMFAChallengeInvalid
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return challenge, errMFAChallengeInvalid
+	}
+	if purpose, _ := claims["purpose"].(string); purpose != mfaChallengePurpose {
+		return challenge, errMFAChallengeInvalid
+	}
+	challenge.ID, _ = claims["jti"].(string)
+	challenge.Username, _ = claims["username"].(string)
+	if challenge.ID == "" || challenge.Username == "" || database.IsTokenRevoked(challenge.ID) {
+		return challenge, errMFAChallengeInvalid
+	}
+	scope, _ := claims["scope"].(string)
+	challenge.Scopes = strings.Fields(scope)
+	if expiresAt, err := claims.GetExpirationTime(); err == nil && expiresAt != nil {
+		challenge.ExpiresAt = expiresAt.Time
+	}
+	return challenge, nil
+}
+
+// MFARequired reports whether the policy requires role to pass a second
+// factor before writing (REQUIRE_MFA_FOR_ADMINS).
+func MFARequired(role models.Role) bool {
+	return role == models.RoleAdmin && os.Getenv("REQUIRE_MFA_FOR_ADMINS") == "true"
+}
+
+// checkMFAPolicy responds with 403 and returns false when a write is made
+// with a token that MFARequired says needs a second factor it lacks. API
+// keys are not subject to the policy.
+func checkMFAPolicy(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	if c.GetString(apiKeyIDKey) != "" || c.GetBool(mfaKey) || !MFARequired(CurrentRole(c)) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":        "Two-factor authentication is required for this action; enrol at /api/v1/mfa/enroll and log in again",
+		"mfa_required": true,
+	})
+	return false
+}
@@ -0,0 +1,290 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+
+	"inventory_management/database"
+	"inventory_management/models"
+)
+
+// oidcSettings is read from the environment on every use so that the
+// provider can be configured without a restart of the test process.
+type oidcSettings struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	audience     string
+	groupsClaim  string
+	tenantClaim  string
+	roleMapping  map[string]models.Role
+	defaultRole  models.Role
+}
+
+// OIDCIdentity is a user as described by the identity provider.
+type OIDCIdentity struct {
+	ExternalID string
+	Username   string
+	Email      string
+	Groups     []string
+	Role       models.Role
+	Tenant     string
+	// Scopes are the scopes of ours the provider granted; all of them
+	// when its token names none.
+	Scopes []string
+	// MFA is whether the provider reports a second factor in its amr
+	// claim; it counts like our own TOTP for REQUIRE_MFA_FOR_ADMINS.
+	MFA bool
+}
+
+var (
+	ErrOIDCDisabled = errors.New("OIDC login is not configured")
+	errOIDCNoRole   = errors.New("None of your identity provider groups grant access")
+	errOIDCNoTenant = errors.New("Your identity provider account has no valid tenant")
+)
+
+var oidcProviders = struct {
+	sync.Mutex
+	byIssuer map[string]*oidc.Provider
+}{byIssuer: make(map[string]*oidc.Provider)}
+
+func loadOIDCSettings() (oidcSettings, bool) {
+	s := oidcSettings{
+		issuer:       strings.TrimSuffix(os.Getenv("OIDC_ISSUER_URL"), "/"),
+		clientID:     os.Getenv("OIDC_CLIENT_ID"),
+		clientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		redirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		audience:     os.Getenv("OIDC_AUDIENCE"),
+		groupsClaim:  os.Getenv("OIDC_GROUPS_CLAIM"),
+		tenantClaim:  os.Getenv("OIDC_TENANT_CLAIM"),
+		defaultRole:  models.Role(os.Getenv("OIDC_DEFAULT_ROLE")),
+		roleMapping:  make(map[string]models.Role),
+	}
+	if s.audience == "" {
+		s.audience = s.clientID
+	}
+	if s.groupsClaim == "" {
+		s.groupsClaim = "groups"
+	}
+	if !s.defaultRole.IsValid() {
+		s.defaultRole = ""
+	}
+	// OIDC_ROLE_MAPPING is a list like "inventory-admins=admin,warehouse=clerk".
+	for _, pair := range strings.Split(os.Getenv("OIDC_ROLE_MAPPING"), ",") {
+		group, role, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && models.Role(role).IsValid() {
+			s.roleMapping[group] = models.Role(role)
+		}
+	}
+	return s, s.issuer != "" && s.clientID != ""
+}
+
+func OIDCEnabled() bool {
+	_, ok := loadOIDCSettings()
+	return ok
+}
+
+// oidcProvider runs discovery once per issuer. Failed discoveries are not
+// cached, so a provider that was down at startup is retried.
+func oidcProvider(ctx context.Context, issuer string) (*oidc.Provider, error) {
+	oidcProviders.Lock()
+	defer oidcProviders.Unlock()
+	if provider, ok := oidcProviders.byIssuer[issuer]; ok {
+		return provider, nil
+	}
+	provider, err := oidc.NewProvider(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+	oidcProviders.byIssuer[issuer] = provider
+	return provider, nil
+}
+
+func oauth2Config(settings oidcSettings, provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     settings.clientID,
+		ClientSecret: settings.clientSecret,
+		RedirectURL:  settings.redirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+	}
+}
+
+// OIDCAuthCodeURL is where to send the browser to log in, with the PKCE
+// challenge derived from verifier.
+func OIDCAuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	settings, ok := loadOIDCSettings()
+	if !ok {
+		return "", ErrOIDCDisabled
+	}
+	provider, err := oidcProvider(ctx, settings.issuer)
+	if err != nil {
+		return "", err
+	}
+	return oauth2Config(settings, provider).AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// OIDCExchange redeems an authorization code and validates the ID token
+// it returns: signature (via the provider's JWKS), issuer, audience,
+// expiry and nonce.
+func OIDCExchange(ctx context.Context, code, verifier, nonce string) (OIDCIdentity, error) {
+	settings, ok := loadOIDCSettings()
+	if !ok {
+		return OIDCIdentity{}, ErrOIDCDisabled
+	}
+	provider, err := oidcProvider(ctx, settings.issuer)
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+
+	token, err := oauth2Config(settings, provider).Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return OIDCIdentity{}, errors.New("token response has no id_token")
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: settings.clientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+	if idToken.Nonce != nonce {
+		return OIDCIdentity{}, errors.New("ID token nonce does not match")
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return OIDCIdentity{}, err
+	}
+	return settings.identity(idToken.Issuer, idToken.Subject, claims)
+}
+
+// isOIDCToken reports whether a bearer token was issued by the configured
+// identity provider rather than by this service. The signature is checked
+// afterwards by the matching verifier.
+func isOIDCToken(raw string) bool {
+	settings, ok := loadOIDCSettings()
+	if !ok {
+		return false
+	}
+	var claims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(raw, &claims); err != nil {
+		return false
+	}
+	return strings.TrimSuffix(claims.Issuer, "/") == settings.issuer
+}
+
+// verifyOIDCAccessToken validates a JWT access token issued by the
+// identity provider for OIDC_AUDIENCE (the client ID by default).
+func verifyOIDCAccessToken(ctx context.Context, raw string) (OIDCIdentity, error) {
+	settings, ok := loadOIDCSettings()
+	if !ok {
+		return OIDCIdentity{}, ErrOIDCDisabled
+	}
+	provider, err := oidcProvider(ctx, settings.issuer)
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+	token, err := provider.Verifier(&oidc.Config{ClientID: settings.audience}).Verify(ctx, raw)
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+
+	var claims map[string]interface{}
+	if err := token.Claims(&claims); err != nil {
+		return OIDCIdentity{}, err
+	}
+	return settings.identity(token.Issuer, token.Subject, claims)
+}
+
+func (s oidcSettings) identity(issuer, subject string, claims map[string]interface{}) (OIDCIdentity, error) {
+	identity := OIDCIdentity{
+		ExternalID: issuer + "|" + subject,
+		Username:   subject,
+		Groups:     stringsClaim(claims[s.groupsClaim]),
+		Tenant:     database.DefaultTenant,
+	}
+	identity.Email, _ = claims["email"].(string)
+	if name, _ := claims["preferred_username"].(string); name != "" {
+		identity.Username = name
+	} else if identity.Email != "" {
+		identity.Username = identity.Email
+	}
+
+	// OIDC_TENANT_CLAIM names a claim holding the user's tenant; without
+	// it everyone belongs to the default tenant.
+	if s.tenantClaim != "" {
+		tenant := stringClaim(claims[s.tenantClaim])
+		if !database.ValidTenantID(tenant) {
+			return identity, errOIDCNoTenant
+		}
+		identity.Tenant = tenant
+	}
+
+	identity.Role = s.defaultRole
+	for _, group := range identity.Groups {
+		if role, ok := s.roleMapping[group]; ok && roleRank(role) > roleRank(identity.Role) {
+			identity.Role = role
+		}
+	}
+	if identity.Role == "" {
+		return identity, errOIDCNoRole
+	}
+
+	for _, scope := range append(strings.Fields(stringClaim(claims["scope"])), stringsClaim(claims["scp"])...) {
+		if containsString(Scopes, scope) {
+			identity.Scopes = append(identity.Scopes, scope)
+		}
+	}
+	if identity.Scopes == nil {
+		identity.Scopes = Scopes
+	}
+
+	for _, method := range stringsClaim(claims["amr"]) {
+		if method == "mfa" || method == "otp" || method == "hwk" {
+			identity.MFA = true
+		}
+	}
+	return identity, nil
+}
+
+func roleRank(role models.Role) int {
+	for i, r := range models.Roles {
+		if r == role {
+			return i
+		}
+	}
+	return -1
+}
+
+func stringClaim(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+// stringsClaim accepts a list of strings or a single space-separated
+// string, the two shapes IdPs use for groups.
+func stringsClaim(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"inventory_management/models"
+)
+
+const (
+	roleKey        = "role"
+	permissionsKey = "permissions"
+)
+
+type Permission string
+
+const (
+	PermInventoryRead   Permission = "inventory:read"
+	PermInventoryStock  Permission = "inventory:stock"
+	PermInventoryTags   Permission = "inventory:tags"
+	PermInventoryWrite  Permission = "inventory:write"
+	PermInventoryPrice  Permission = "inventory:price"
+	PermInventoryDelete Permission = "inventory:delete"
+	PermInventoryImport Permission = "inventory:import"
+	PermAuditRead       Permission = "audit:read"
+	PermUsersManage     Permission = "users:manage"
+	PermAPIKeysManage   Permission = "apikeys:manage"
+)
+
+// rolePermissions is the permission matrix; each role adds to the one
+// before it. Reading the inventory only needs a permission when
+// REQUIRE_AUTH_FOR_READS is set.
+var rolePermissions = map[models.Role][]Permission{
+	models.RoleViewer: {PermInventoryRead},
+	models.RoleClerk:  {PermInventoryRead, PermInventoryStock, PermInventoryTags},
+	models.RoleManager: {
+		PermInventoryRead, PermInventoryStock, PermInventoryTags, PermInventoryWrite, PermInventoryPrice,
+		PermInventoryDelete, PermInventoryImport, PermAuditRead,
+	},
+	models.RoleAdmin: {
+		PermInventoryRead, PermInventoryStock, PermInventoryTags, PermInventoryWrite, PermInventoryPrice,
+		PermInventoryDelete, PermInventoryImport, PermAuditRead, PermUsersManage, PermAPIKeysManage,
+	},
+}
+
+// APIKeyScopes are the scopes an API key can be granted: permissions, of
+// which inventory:read, inventory:write and inventory:delete are also token
+// scopes and grant what they grant a token. Managing users and keys is
+// left to people.
+var APIKeyScopes = []Permission{
+	PermInventoryRead, PermInventoryStock, PermInventoryTags, PermInventoryWrite, PermInventoryPrice,
+	PermInventoryDelete, PermInventoryImport, PermAuditRead,
+}
+
+func IsAPIKeyScope(scope string) bool {
+	for _, p := range APIKeyScopes {
+		if string(p) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func CurrentRole(c *gin.Context) models.Role {
+	role, _ := c.Get(roleKey)
+	r, _ := role.(models.Role)
+	return r
+}
+
+// HasPermission reports whether the authenticated user or API key may do
+// what permission allows. It is false for unauthenticated requests.
+func HasPermission(c *gin.Context, permission Permission) bool {
+	granted, _ := c.Get(permissionsKey)
+	permissions, _ := granted.([]Permission)
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission must run after JWTAuthMiddleware; it rejects users
+// missing any of the permissions with 403.
+func RequirePermission(permissions ...Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, permission := range permissions {
+			if !HasPermission(c, permission) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Missing permission: " + string(permission)})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
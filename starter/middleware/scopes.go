@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"inventory_management/models"
+)
+
+// OAuth-style token scopes. Each unlocks a group of permissions; a token
+// can do what both its role and its scopes allow.
+const (
+	ScopeInventoryRead   = "inventory:read"
+	ScopeInventoryWrite  = "inventory:write"
+	ScopeInventoryDelete = "inventory:delete"
+	ScopeAdmin           = "admin"
+)
+
+var Scopes = []string{ScopeInventoryRead, ScopeInventoryWrite, ScopeInventoryDelete, ScopeAdmin}
+
+var scopePermissions = map[string][]Permission{
+	ScopeInventoryRead: {PermInventoryRead},
+	ScopeInventoryWrite: {
+		PermInventoryStock, PermInventoryTags, PermInventoryWrite, PermInventoryPrice, PermInventoryImport,
+	},
+	ScopeInventoryDelete: {PermInventoryDelete},
+	ScopeAdmin:           {PermAuditRead, PermUsersManage, PermAPIKeysManage},
+}
+
+// ParseScope splits a space-separated scope parameter and rejects unknown
+// scopes. An empty parameter yields no scopes, meaning every scope.
+func ParseScope(param string) ([]string, error) {
+	scopes := strings.Fields(param)
+	for _, scope := range scopes {
+		if _, ok := scopePermissions[scope]; !ok {
+			return nil, fmt.Errorf("Unknown scope: %s (valid scopes: %s)", scope, strings.Join(Scopes, ", "))
+		}
+	}
+	return scopes, nil
+}
+
+// CheckScopes rejects requested scopes that give role no permission, so
+// that asking for more than the role allows fails instead of producing a
+// token that can do nothing.
+func CheckScopes(role models.Role, requested []string) error {
+	for _, scope := range requested {
+		if len(effectivePermissions(role, []string{scope})) == 0 {
+			return fmt.Errorf("Scope %s is not available to the %s role", scope, role)
+		}
+	}
+	return nil
+}
+
+// GrantedScopes narrows requested (empty for all) to the scopes that give
+// role at least one permission, in canonical order.
+func GrantedScopes(role models.Role, requested []string) []string {
+	granted := []string{}
+	for _, scope := range Scopes {
+		if len(requested) > 0 && !containsString(requested, scope) {
+			continue
+		}
+		if len(effectivePermissions(role, []string{scope})) > 0 {
+			granted = append(granted, scope)
+		}
+	}
+	return granted
+}
+
+// RequireReadAccess guards the inventory reads. They are public unless
+// REQUIRE_AUTH_FOR_READS is true; then they need a token or API key with
+// inventory:read. Credentials sent to a public read are still checked, so
+// that it is served from their tenant; anonymous reads only see the
+// default tenant.
+func RequireReadAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		required := os.Getenv("REQUIRE_AUTH_FOR_READS") == "true"
+		hasCredentials := c.GetHeader("Authorization") != "" || c.GetHeader(apiKeyHeader) != ""
+		if !required && !hasCredentials {
+			if !requireTenantCredentials(c) {
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+		if !authorizeRequest(c) {
+			c.Abort()
+			return
+		}
+		if required && !HasPermission(c, PermInventoryRead) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Missing permission: " + string(PermInventoryRead)})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func effectivePermissions(role models.Role, scopes []string) []Permission {
+	var permissions []Permission
+	for _, scope := range scopes {
+		for _, permission := range scopePermissions[scope] {
+			if roleHasPermission(role, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return permissions
+}
+
+func roleHasPermission(role models.Role, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
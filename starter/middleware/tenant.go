@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"inventory_management/database"
+)
+
+const (
+	tenantKey    = "tenant"
+	TenantHeader = "X-Tenant-ID"
+)
+
+// TenantMiddleware puts anonymous requests in the default tenant.
+// Authenticated requests act for the tenant of their user or API key; the
+// X-Tenant-ID header only states which tenant a client expects, and is
+// never trusted on its own.
+func TenantMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tenant := c.GetHeader(TenantHeader); tenant != "" && !database.ValidTenantID(tenant) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + TenantHeader})
+			c.Abort()
+			return
+		}
+		c.Set(tenantKey, database.DefaultTenant)
+		c.Next()
+	}
+}
+
+func CurrentTenant(c *gin.Context) string {
+	if tenant := c.GetString(tenantKey); tenant != "" {
+		return tenant
+	}
+	return database.DefaultTenant
+}
+
+// TenantDB is the database as seen by the current tenant; handlers use it
+// for everything that reads or writes tenant-owned rows.
+func TenantDB(c *gin.Context) *gorm.DB {
+	return database.DB.WithContext(database.WithTenant(c.Request.Context(), CurrentTenant(c)))
+}
+
+// requireTenantCredentials responds with 401 and returns false when an
+// anonymous request names a tenant other than the default one.
+func requireTenantCredentials(c *gin.Context) bool {
+	if requested := c.GetHeader(TenantHeader); requested != "" && requested != database.DefaultTenant {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required for tenant " + requested})
+		return false
+	}
+	return true
+}
+
+// setAuthenticatedTenant switches the request to the tenant of its
+// credentials. Naming another tenant in X-Tenant-ID is answered with 403.
+func setAuthenticatedTenant(c *gin.Context, tenant string) bool {
+	if requested := c.GetHeader(TenantHeader); requested != "" && requested != tenant {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your credentials are not valid for tenant " + requested})
+		return false
+	}
+	c.Set(tenantKey, tenant)
+	return true
+}
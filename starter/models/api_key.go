@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// APIKey authenticates a machine client. The key itself is shown once on
+// creation; only its Prefix, which identifies it in listings and lookups,
+// and a SHA-256 hash of the secret part are stored.
+type APIKey struct {
+	ID         string     `json:"id" gorm:"primaryKey"`
+	TenantID   string     `json:"tenant_id" gorm:"not null;default:default;index"`
+	Name       string     `json:"name" gorm:"not null"`
+	Prefix     string     `json:"prefix" gorm:"uniqueIndex;not null"`
+	KeyHash    string     `json:"-" gorm:"not null"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json"`
+	CreatedBy  string     `json:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (k APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
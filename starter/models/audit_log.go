@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type AuditLog struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	TenantID   string    `json:"tenant_id" gorm:"not null;default:default;index"`
+	Actor      string    `json:"actor" gorm:"index"`
+	Action     string    `json:"action" gorm:"index;not null"`
+	Resource   string    `json:"resource" gorm:"index;not null"`
+	ResourceID string    `json:"resource_id,omitempty" gorm:"index"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	StatusCode int       `json:"status_code"`
+	Before     JSON      `json:"before,omitempty"`
+	After      JSON      `json:"after,omitempty"`
+	Diff       JSON      `json:"diff,omitempty"`
+	RequestID  string    `json:"request_id" gorm:"index"`
+	ClientIP   string    `json:"client_ip"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
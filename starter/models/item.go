@@ -1,8 +1,69 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ItemStatus string
+
+const (
+	ItemStatusDraft        ItemStatus = "draft"
+	ItemStatusActive       ItemStatus = "active"
+	ItemStatusDiscontinued ItemStatus = "discontinued"
+	ItemStatusArchived     ItemStatus = "archived"
+)
+
+var itemStatusTransitions = map[ItemStatus][]ItemStatus{
+	ItemStatusDraft:        {ItemStatusActive, ItemStatusArchived},
+	ItemStatusActive:       {ItemStatusDiscontinued, ItemStatusArchived},
+	ItemStatusDiscontinued: {ItemStatusActive, ItemStatusArchived},
+	ItemStatusArchived:     {},
+}
+
 type Item struct {
-	ID    string  `json:"id" gorm:"primaryKey"`
-	Name  string  `json:"name" gorm:"not null" binding:"required,min=1,max=100"`
-	Stock int     `json:"stock" gorm:"not null" binding:"required,min=0"`
-	Price float64 `json:"price" gorm:"not null" binding:"required,gt=0"`
+	ID        string         `json:"id" gorm:"primaryKey"`
+	TenantID  string         `json:"-" gorm:"not null;default:default;uniqueIndex:idx_items_tenant_sku"`
+	SKU       *string        `json:"sku,omitempty" gorm:"uniqueIndex:idx_items_tenant_sku" binding:"omitempty,min=1,max=64"`
+	Name      string         `json:"name" gorm:"not null" binding:"required,min=1,max=100"`
+	Stock     int            `json:"stock" gorm:"not null" binding:"min=0"`
+	Price     float64        `json:"price" gorm:"not null" binding:"required,gt=0"`
+	Status    ItemStatus     `json:"status" gorm:"not null;default:active;index" binding:"omitempty,oneof=draft active discontinued archived"`
+	Version   int            `json:"version" gorm:"not null;default:1"`
+	Tags      []Tag          `json:"tags,omitempty" gorm:"many2many:item_tags;"`
+	CreatedAt time.Time      `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP;index"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+func (s ItemStatus) IsValid() bool {
+	_, ok := itemStatusTransitions[s]
+	return ok
+}
+
+func (s ItemStatus) CanTransitionTo(next ItemStatus) bool {
+	if s == next {
+		return true
+	}
+	for _, allowed := range itemStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Only active items can be sold; discontinued items may still receive
+// outstanding purchase orders but never lose stock.
+func (s ItemStatus) AcceptsOrders() bool {
+	return s == ItemStatusActive
+}
+
+func (s ItemStatus) AllowsStockDecrement() bool {
+	return s == ItemStatusActive || s == ItemStatusDraft
+}
+
+func (s ItemStatus) AllowsStockIncrement() bool {
+	return s != ItemStatusArchived
 }
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"crypto/subtle"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const totpPeriod = 30
+
+// RecoveryCode stands in for a TOTP code once, e.g. when the
+// authenticator is lost. Only a SHA-256 hash of the code is stored.
+type RecoveryCode struct {
+	ID        string     `json:"id" gorm:"primaryKey"`
+	UserID    string     `json:"user_id" gorm:"index;not null"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// GenerateTOTPSecret starts an enrolment: it sets a new MFASecret and
+// returns the key for the user's authenticator app.
+func (u *User) GenerateTOTPSecret(issuer string) (*otp.Key, error) {
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: issuer, AccountName: u.Username, Period: totpPeriod})
+	if err != nil {
+		return nil, err
+	}
+	u.MFASecret = key.Secret()
+	return key, nil
+}
+
+// CheckTOTP reports whether code is valid at now, allowing one period of
+// clock skew either way, and returns the time step it belongs to.
+func (u *User) CheckTOTP(code string, now time.Time) (int64, bool) {
+	if u == nil || u.MFASecret == "" || len(code) != int(otp.DigitsSix) {
+		return 0, false
+	}
+	step := now.Unix() / totpPeriod
+	for _, candidate := range []int64{step, step - 1, step + 1} {
+		expected, err := totp.GenerateCodeCustom(u.MFASecret, time.Unix(candidate*totpPeriod, 0), totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err == nil && subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return candidate, true
+		}
+	}
+	return 0, false
+}
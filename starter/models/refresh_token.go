@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// RefreshToken stores only a SHA-256 hash of the token handed to the
+// client. Tokens issued by rotating one another share a FamilyID, so a
+// reused token can revoke the whole chain. Scope is what the session was
+// granted at login (empty for every scope) and carries over on rotation,
+// as does MFA, whether the login passed a second factor.
+type RefreshToken struct {
+	ID         string     `json:"id" gorm:"primaryKey"`
+	UserID     string     `json:"user_id" gorm:"index;not null"`
+	FamilyID   string     `json:"family_id" gorm:"index;not null"`
+	Scope      string     `json:"scope"`
+	MFA        bool       `json:"mfa" gorm:"not null;default:false"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"index;not null"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ReplacedBy string     `json:"replaced_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
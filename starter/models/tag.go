@@ -0,0 +1,13 @@
+package models
+
+import "strings"
+
+type Tag struct {
+	ID       string `json:"id" gorm:"primaryKey"`
+	TenantID string `json:"-" gorm:"not null;default:default;uniqueIndex:idx_tags_tenant_name"`
+	Name     string `json:"name" gorm:"uniqueIndex:idx_tags_tenant_name;not null"`
+}
+
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
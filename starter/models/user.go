@@ -0,0 +1,82 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	MinPasswordLength = 8
+	// bcrypt ignores everything after the first 72 bytes.
+	MaxPasswordLength = 72
+)
+
+var ErrInvalidPasswordLength = errors.New("Password must be between 8 and 72 characters")
+
+type Role string
+
+const (
+	RoleViewer  Role = "viewer"
+	RoleClerk   Role = "clerk"
+	RoleManager Role = "manager"
+	RoleAdmin   Role = "admin"
+)
+
+var Roles = []Role{RoleViewer, RoleClerk, RoleManager, RoleAdmin}
+
+func (r Role) IsValid() bool {
+	for _, role := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// User is an account. With MFAEnabled, logins also need a TOTP code for
+// MFASecret; while MFAEnabled is false a set MFASecret belongs to an
+// enrolment that was not confirmed yet. MFALastStep is the time step of
+// the last code accepted, so no code is accepted twice.
+type User struct {
+	ID                string    `json:"id" gorm:"primaryKey"`
+	TenantID          string    `json:"tenant_id" gorm:"not null;default:default;index"`
+	Username          string    `json:"username" gorm:"uniqueIndex;not null"`
+	PasswordHash      string    `json:"-" gorm:"not null"`
+	ExternalID        *string   `json:"external_id,omitempty" gorm:"uniqueIndex"`
+	Role              Role      `json:"role" gorm:"not null;default:viewer"`
+	Disabled          bool      `json:"disabled" gorm:"not null;default:false"`
+	MFAEnabled        bool      `json:"mfa_enabled" gorm:"not null;default:false"`
+	MFASecret         string    `json:"-"`
+	MFALastStep       int64     `json:"-" gorm:"not null;default:0"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+func (u *User) SetPassword(password string) error {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return ErrInvalidPasswordLength
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.PasswordHash = string(hash)
+	u.PasswordChangedAt = time.Now()
+	return nil
+}
+
+// CheckPassword compares in constant time. A nil user, or one that signs
+// in through an identity provider and has no password, is checked against
+// a dummy hash so unknown usernames take as long as wrong passwords.
+func (u *User) CheckPassword(password string) bool {
+	if u == nil || u.PasswordHash == "" {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
+
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password for timing"), bcrypt.DefaultCost)
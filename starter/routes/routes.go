@@ -3,16 +3,26 @@ package routes
 import (
 	"inventory_management/handlers"
 	"inventory_management/middleware"
+	"log"
+	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 func SetupRoutes() *gin.Engine {
 	router := gin.Default()
+	// X-Forwarded-For is only believed from TRUSTED_PROXIES (a comma
+	// separated list of IPs or CIDRs); otherwise any client could pick the
+	// IP that rate limits and login lockouts count against.
+	if err := router.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Tenant-ID, X-Request-ID, If-Match, If-None-Match, If-Modified-Since")
+		c.Header("Access-Control-Expose-Headers", "ETag, Last-Modified, X-Request-ID")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -22,20 +32,82 @@ func SetupRoutes() *gin.Engine {
 		c.Next()
 	})
 
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(middleware.TenantMiddleware())
 	router.Use(middleware.RateLimiterMiddleware())
+	router.Use(middleware.AuditMiddleware())
+
+	auth := middleware.JWTAuthMiddleware()
+	can := middleware.RequirePermission
+	read := middleware.RequireReadAccess()
+	// enroll skips the MFA policy for what admins need before enrolling.
+	enroll := middleware.MFAEnrollmentAuthMiddleware()
+
+	router.GET("/.well-known/jwks.json", handlers.GetJWKS) // GET /.well-known/jwks.json
 
 	api := router.Group("/api/v1")
 	{
 		api.POST("/login", handlers.Login)
+		api.POST("/login/mfa", handlers.LoginMFA)                                     // POST /api/v1/login/mfa
+		api.GET("/auth/oidc/login", handlers.OIDCLogin)                               // GET /api/v1/auth/oidc/login
+		api.GET("/auth/oidc/callback", handlers.OIDCCallback)                         // GET /api/v1/auth/oidc/callback
+		api.POST("/token/refresh", handlers.RefreshToken)                             // POST /api/v1/token/refresh
+		api.POST("/logout", enroll, handlers.Logout)                                  // POST /api/v1/logout
+		api.GET("/tags", read, handlers.GetAllTags)                                   // GET /api/v1/tags
+		api.GET("/audit", auth, can(middleware.PermAuditRead), handlers.GetAuditLogs) // GET /api/v1/audit
+		mfa := api.Group("/mfa")
+		{
+			mfa.POST("/enroll", enroll, handlers.EnrollMFA)                     // POST /api/v1/mfa/enroll
+			mfa.POST("/verify", enroll, handlers.VerifyMFA)                     // POST /api/v1/mfa/verify
+			mfa.POST("/recovery-codes", auth, handlers.RegenerateRecoveryCodes) // POST /api/v1/mfa/recovery-codes
+			mfa.DELETE("", auth, handlers.DisableMFA)                           // DELETE /api/v1/mfa
+		}
+		users := api.Group("/users")
+		{
+			users.GET("", auth, can(middleware.PermUsersManage), handlers.GetUsers)                 // GET /api/v1/users
+			users.POST("", auth, can(middleware.PermUsersManage), handlers.CreateUser)              // POST /api/v1/users
+			users.POST("/:id/disable", auth, can(middleware.PermUsersManage), handlers.DisableUser) // POST /api/v1/users/:id/disable
+			users.POST("/:id/enable", auth, can(middleware.PermUsersManage), handlers.EnableUser)   // POST /api/v1/users/:id/enable
+			users.PUT("/:id/role", auth, can(middleware.PermUsersManage), handlers.SetUserRole)     // PUT /api/v1/users/:id/role
+			users.PUT("/:id/password", auth, handlers.ChangePassword)                               // PUT /api/v1/users/:id/password
+			users.DELETE("/:id/mfa", auth, can(middleware.PermUsersManage), handlers.ResetUserMFA)  // DELETE /api/v1/users/:id/mfa
+		}
+		apiKeys := api.Group("/api-keys")
+		{
+			apiKeys.GET("", auth, can(middleware.PermAPIKeysManage), handlers.GetAPIKeys)          // GET /api/v1/api-keys
+			apiKeys.POST("", auth, can(middleware.PermAPIKeysManage), handlers.CreateAPIKey)       // POST /api/v1/api-keys
+			apiKeys.DELETE("/:id", auth, can(middleware.PermAPIKeysManage), handlers.RevokeAPIKey) // DELETE /api/v1/api-keys/:id
+		}
 		items := api.Group("/inventory")
 		{
-			items.GET("", handlers.GetAllItems)                                       // GET /api/v1/inventory
-			items.GET("/:id", handlers.GetItemByID)                                   // GET /api/v1/inventory/:id
-			items.POST("", middleware.JWTAuthMiddleware(), handlers.CreateItem)       // POST /api/v1/inventory
-			items.PUT("/:id", middleware.JWTAuthMiddleware(), handlers.UpdateItem)    // PUT /api/v1/inventory/:id
-			items.DELETE("/:id", middleware.JWTAuthMiddleware(), handlers.DeleteItem) // DELETE /api/v1/inventory/:id
+			items.GET("", read, handlers.GetAllItems)                                                                    // GET /api/v1/inventory
+			items.GET("/:id", read, handlers.GetItemByID)                                                                // GET /api/v1/inventory/:id
+			items.POST("", auth, can(middleware.PermInventoryWrite, middleware.PermInventoryPrice), handlers.CreateItem) // POST /api/v1/inventory
+			items.PUT("/:id", auth, handlers.UpdateItem)                                                                 // PUT /api/v1/inventory/:id
+			items.PATCH("/:id", auth, handlers.PatchItem)                                                                // PATCH /api/v1/inventory/:id
+			items.DELETE("/:id", auth, can(middleware.PermInventoryDelete), handlers.DeleteItem)                         // DELETE /api/v1/inventory/:id
+			items.POST("/:id/stock", auth, can(middleware.PermInventoryStock), handlers.AdjustStock)                     // POST /api/v1/inventory/:id/stock
+			items.POST("/:id/restore", auth, can(middleware.PermInventoryDelete), handlers.RestoreItem)                  // POST /api/v1/inventory/:id/restore
+
+			items.POST("/bulk", auth, can(middleware.PermInventoryWrite, middleware.PermInventoryPrice, middleware.PermInventoryDelete), handlers.BulkItems) // POST /api/v1/inventory/bulk
+			items.POST("/import", auth, can(middleware.PermInventoryImport), handlers.ImportItems)                                                           // POST /api/v1/inventory/import
+			items.GET("/export", read, handlers.ExportItems)                                                                                                 // GET /api/v1/inventory/export
+			items.GET("/search", read, handlers.SearchItems)                                                                                                 // GET /api/v1/inventory/search
+			items.POST("/tags", auth, can(middleware.PermInventoryTags), handlers.BulkUpdateTags)                                                            // POST /api/v1/inventory/tags
+			items.POST("/:id/tags", auth, can(middleware.PermInventoryTags), handlers.AddItemTags)                                                           // POST /api/v1/inventory/:id/tags
+			items.DELETE("/:id/tags", auth, can(middleware.PermInventoryTags), handlers.RemoveItemTags)                                                      // DELETE /api/v1/inventory/:id/tags
 		}
 	}
 
 	return router
 }
+
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
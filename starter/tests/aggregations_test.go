@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/handlers"
+	"inventory_management/models"
+)
+
+type AggregationsTestSuite struct {
+	apiSuite
+}
+
+func (suite *AggregationsTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+
+	items := []models.Item{
+		{ID: "1", Name: "Cable", Stock: 40, Price: 5.00},
+		{ID: "2", Name: "Mouse", Stock: 10, Price: 25.00},
+		{ID: "3", Name: "Monitor", Stock: 4, Price: 250.00},
+		{ID: "4", Name: "Laptop", Stock: 2, Price: 1200.00},
+		{ID: "5", Name: "Old Mouse", Stock: 7, Price: 15.00, Status: models.ItemStatusArchived},
+	}
+	suite.db.Create(&items)
+}
+
+func (suite *AggregationsTestSuite) TestAggregationsCoverTheFilteredSet() {
+	w := performRequest(suite.router, "GET", "/api/v1/inventory?aggregations=true&price_buckets=0,10,100,1000&stock_buckets=5,20&page_size=1", nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var response handlers.PaginationResponse
+	decodeJSON(suite.T(), w, &response)
+	require.NotNil(suite.T(), response.Aggregations)
+	agg := response.Aggregations
+	assert.Len(suite.T(), response.Data, 1)
+	assert.Equal(suite.T(), int64(4), agg.Count)
+	assert.Equal(suite.T(), int64(56), agg.TotalStock)
+	assert.Equal(suite.T(), 3850.0, agg.InventoryValue)
+	assert.Equal(suite.T(), 5.0, *agg.MinPrice)
+	assert.Equal(suite.T(), 1200.0, *agg.MaxPrice)
+	assert.Equal(suite.T(), 370.0, *agg.AvgPrice)
+
+	priceCounts := make([]int64, len(agg.PriceHistogram))
+	for i, bucket := range agg.PriceHistogram {
+		priceCounts[i] = bucket.Count
+	}
+	assert.Equal(suite.T(), []int64{0, 1, 1, 1, 1}, priceCounts)
+	assert.Nil(suite.T(), agg.PriceHistogram[0].From)
+	assert.Nil(suite.T(), agg.PriceHistogram[4].To)
+
+	stockCounts := make([]int64, len(agg.StockHistogram))
+	for i, bucket := range agg.StockHistogram {
+		stockCounts[i] = bucket.Count
+	}
+	assert.Equal(suite.T(), []int64{2, 1, 1}, stockCounts)
+}
+
+func (suite *AggregationsTestSuite) TestAggregationsRespectFiltersAndEmptySets() {
+	w := performRequest(suite.router, "GET", "/api/v1/inventory?aggregations=true&name=mouse&status=all", nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var response handlers.PaginationResponse
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), int64(2), response.Aggregations.Count)
+	assert.Equal(suite.T(), 355.0, response.Aggregations.InventoryValue)
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory?aggregations=true&name=nothing", nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	response = handlers.PaginationResponse{}
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), int64(0), response.Aggregations.Count)
+	assert.Nil(suite.T(), response.Aggregations.AvgPrice)
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory?limit=2&aggregations=true", nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var cursorResponse handlers.CursorPaginationResponse
+	decodeJSON(suite.T(), w, &cursorResponse)
+	assert.Equal(suite.T(), int64(4), cursorResponse.Aggregations.Count)
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory?aggregations=true&price_buckets=10,5", nil, "")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory", nil, "")
+	assert.NotContains(suite.T(), w.Body.String(), "aggregations")
+}
+
+func TestAggregationsTestSuite(t *testing.T) {
+	suite.Run(t, new(AggregationsTestSuite))
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/database"
+	"inventory_management/models"
+)
+
+type APIKeyTestSuite struct {
+	apiSuite
+}
+
+type createdAPIKey struct {
+	Key  string        `json:"key"`
+	Data models.APIKey `json:"data"`
+}
+
+func (suite *APIKeyTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+	suite.db.Where("1 = 1").Delete(&models.APIKey{})
+	suite.db.Where("1 = 1").Delete(&models.AuditLog{})
+
+	suite.db.Create(&models.Item{ID: "1", Name: "Scanner", Stock: 10, Price: 250.00, Status: models.ItemStatusActive})
+}
+
+func (suite *APIKeyTestSuite) TestScopedKeyCanOnlyUseItsScopes() {
+	w := performRequest(suite.router, "POST", "/api/v1/api-keys",
+		gin.H{"name": "dock scanner", "scopes": []string{"inventory:stock"}}, suite.jwtToken)
+	require.Equal(suite.T(), http.StatusCreated, w.Code)
+	var created createdAPIKey
+	decodeJSON(suite.T(), w, &created)
+	assert.True(suite.T(), strings.HasPrefix(created.Key, "inv_"+created.Data.Prefix+"_"))
+
+	w = performKeyRequest(suite.router, "POST", "/api/v1/inventory/1/stock", `{"delta": 5, "reason": "receipt"}`, created.Key)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performKeyRequest(suite.router, "DELETE", "/api/v1/inventory/1", "", created.Key)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	var stored models.APIKey
+	require.NoError(suite.T(), suite.db.First(&stored, "id = ?", created.Data.ID).Error)
+	assert.NotNil(suite.T(), stored.LastUsedAt)
+	assert.NotContains(suite.T(), created.Key, stored.KeyHash)
+
+	var entry models.AuditLog
+	require.NoError(suite.T(), suite.db.Where("action = ?", "adjust_stock").First(&entry).Error)
+	assert.Equal(suite.T(), "api_key:"+created.Data.Prefix, entry.Actor)
+}
+
+func (suite *APIKeyTestSuite) TestInvalidKeysAreRejected() {
+	w := performRequest(suite.router, "POST", "/api/v1/api-keys",
+		gin.H{"name": "escalation", "scopes": []string{"users:manage"}}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	past := time.Now().Add(-time.Hour)
+	expired, _, err := database.CreateAPIKey(suite.db, models.APIKey{Name: "old", Scopes: []string{"inventory:stock"}, ExpiresAt: &past})
+	require.NoError(suite.T(), err)
+	w = performKeyRequest(suite.router, "POST", "/api/v1/inventory/1/stock", `{"delta": 1, "reason": "receipt"}`, expired)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	valid, _, err := database.CreateAPIKey(suite.db, models.APIKey{Name: "erp", Scopes: []string{"inventory:stock"}})
+	require.NoError(suite.T(), err)
+	w = performKeyRequest(suite.router, "POST", "/api/v1/inventory/1/stock", `{"delta": 1, "reason": "receipt"}`, valid+"x")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *APIKeyTestSuite) TestRevokedKeyStopsWorking() {
+	raw, key, err := database.CreateAPIKey(suite.db, models.APIKey{Name: "erp", Scopes: []string{"audit:read"}})
+	require.NoError(suite.T(), err)
+
+	w := performKeyRequest(suite.router, "GET", "/api/v1/audit", "", raw)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performRequest(suite.router, "DELETE", "/api/v1/api-keys/"+key.ID, nil, suite.jwtToken)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performKeyRequest(suite.router, "GET", "/api/v1/audit", "", raw)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/api-keys", nil, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), raw)
+	assert.NotContains(suite.T(), w.Body.String(), "key_hash")
+}
+
+func (suite *APIKeyTestSuite) TestTokenScopesMeanTheSameForKeys() {
+	raw, _, err := database.CreateAPIKey(suite.db, models.APIKey{Name: "erp", Scopes: []string{"inventory:write"}})
+	require.NoError(suite.T(), err)
+
+	w := performKeyRequest(suite.router, "POST", "/api/v1/inventory/1/stock", `{"delta": 2, "reason": "receipt"}`, raw)
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	w = performKeyRequest(suite.router, "DELETE", "/api/v1/inventory/1", "", raw)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *APIKeyTestSuite) TestKeysCannotManageKeys() {
+	raw, _, err := database.CreateAPIKey(suite.db, models.APIKey{Name: "erp", Scopes: []string{"inventory:write"}})
+	require.NoError(suite.T(), err)
+
+	w := performKeyRequest(suite.router, "GET", "/api/v1/api-keys", "", raw)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *APIKeyTestSuite) TestKeysCannotLogOut() {
+	raw, _, err := database.CreateAPIKey(suite.db, models.APIKey{Name: "erp", Scopes: []string{"inventory:read"}})
+	require.NoError(suite.T(), err)
+
+	w := performKeyRequest(suite.router, "POST", "/api/v1/logout", `{"refresh_token": "anything"}`, raw)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = performKeyRequest(suite.router, "GET", "/api/v1/inventory/1", "", raw)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func performKeyRequest(router *gin.Engine, method, path, body, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("X-API-Key", key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeyTestSuite(t *testing.T) {
+	suite.Run(t, new(APIKeyTestSuite))
+}
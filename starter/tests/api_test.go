@@ -33,8 +33,9 @@ func (suite *ItemTestSuite) SetupSuite() {
 
 	database.DB = suite.db
 
-	err = suite.db.AutoMigrate(&models.Item{})
+	err = database.Migrate(suite.db)
 	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), database.SeedAdminUser(suite.db))
 
 	gin.SetMode(gin.TestMode)
 	suite.router = routes.SetupRoutes()
@@ -53,7 +54,7 @@ func (suite *ItemTestSuite) SetupSuite() {
 }
 
 func (suite *ItemTestSuite) SetupTest() {
-	suite.db.Where("1 = 1").Delete(&models.Item{})
+	suite.db.Unscoped().Where("1 = 1").Delete(&models.Item{})
 }
 
 func (suite *ItemTestSuite) TestCreateItem() {
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/handlers"
+	"inventory_management/models"
+)
+
+type AuditTestSuite struct {
+	apiSuite
+}
+
+func (suite *AuditTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+	suite.db.Where("1 = 1").Delete(&models.AuditLog{})
+}
+
+func (suite *AuditTestSuite) TestWritesAreAuditedWithDiff() {
+	w := performRequest(suite.router, "POST", "/api/v1/inventory",
+		gin.H{"name": "Stapler", "stock": 5, "price": 12.50}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var created struct {
+		Data models.Item `json:"data"`
+	}
+	decodeJSON(suite.T(), w, &created)
+	id := created.Data.ID
+
+	w = performRequest(suite.router, "PUT", "/api/v1/inventory/"+id,
+		gin.H{"name": "Stapler", "stock": 5, "price": 15.00}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	requestID := w.Header().Get("X-Request-ID")
+	assert.NotEmpty(suite.T(), requestID)
+
+	w = performRequest(suite.router, "GET", "/api/v1/audit?resource=item&resource_id="+id, nil, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response handlers.AuditLogResponse
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), int64(2), response.Total)
+
+	update := response.Data[0]
+	assert.Equal(suite.T(), "update", update.Action)
+	assert.Equal(suite.T(), "admin", update.Actor)
+	assert.Equal(suite.T(), requestID, update.RequestID)
+
+	var diff map[string]map[string]interface{}
+	assert.NoError(suite.T(), json.Unmarshal(update.Diff, &diff))
+	assert.Equal(suite.T(), 12.5, diff["price"]["from"])
+	assert.Equal(suite.T(), 15.0, diff["price"]["to"])
+	assert.NotContains(suite.T(), diff, "name")
+}
+
+func (suite *AuditTestSuite) TestFailedLoginIsAudited() {
+	w := performRequest(suite.router, "POST", "/api/v1/login",
+		gin.H{"username": "mallory", "password": "guess"}, "")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	var entry models.AuditLog
+	assert.NoError(suite.T(), suite.db.Where("action = ?", "login").First(&entry).Error)
+	assert.Equal(suite.T(), "mallory", entry.Actor)
+	assert.Equal(suite.T(), http.StatusUnauthorized, entry.StatusCode)
+}
+
+func (suite *AuditTestSuite) TestAuditRequiresAuthentication() {
+	w := performRequest(suite.router, "GET", "/api/v1/audit", nil, "")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func TestAuditTestSuite(t *testing.T) {
+	suite.Run(t, new(AuditTestSuite))
+}
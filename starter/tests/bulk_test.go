@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/handlers"
+	"inventory_management/models"
+)
+
+type BulkTestSuite struct {
+	apiSuite
+}
+
+func (suite *BulkTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+
+	items := []models.Item{
+		{ID: "1", Name: "Pen", Stock: 100, Price: 1.50},
+		{ID: "2", Name: "Pencil", Stock: 200, Price: 0.75},
+	}
+	suite.db.Create(&items)
+}
+
+func (suite *BulkTestSuite) countItems() int64 {
+	var count int64
+	suite.db.Model(&models.Item{}).Count(&count)
+	return count
+}
+
+func (suite *BulkTestSuite) TestAtomicBulkAppliesAllOperations() {
+	w := performRequest(suite.router, "POST", "/api/v1/inventory/bulk", gin.H{
+		"operations": []gin.H{
+			{"op": "create", "item": gin.H{"name": "Eraser", "stock": 50, "price": 0.50}},
+			{"op": "update", "id": "1", "version": 1, "item": gin.H{"name": "Pen", "stock": 90, "price": 1.60}},
+			{"op": "delete", "id": "2"},
+		},
+	}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response handlers.BulkResponse
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), 3, response.Succeeded)
+	assert.Equal(suite.T(), http.StatusCreated, response.Results[0].Status)
+	assert.Equal(suite.T(), int64(2), suite.countItems())
+}
+
+func (suite *BulkTestSuite) TestAtomicBulkRollsBackOnFailure() {
+	w := performRequest(suite.router, "POST", "/api/v1/inventory/bulk", gin.H{
+		"mode": "atomic",
+		"operations": []gin.H{
+			{"op": "create", "item": gin.H{"name": "Eraser", "stock": 50, "price": 0.50}},
+			{"op": "update", "id": "missing", "item": gin.H{"name": "Ghost", "stock": 1, "price": 1.00}},
+		},
+	}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+
+	var response handlers.BulkResponse
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), http.StatusFailedDependency, response.Results[0].Status)
+	assert.Equal(suite.T(), http.StatusNotFound, response.Results[1].Status)
+	assert.Equal(suite.T(), int64(2), suite.countItems())
+}
+
+func (suite *BulkTestSuite) TestBestEffortBulkReportsPerRowErrors() {
+	w := performRequest(suite.router, "POST", "/api/v1/inventory/bulk", gin.H{
+		"mode": "best_effort",
+		"operations": []gin.H{
+			{"op": "create", "item": gin.H{"name": "Eraser", "stock": 50, "price": 0.50}},
+			{"op": "create", "item": gin.H{"name": "Broken", "stock": 5, "price": -1}},
+			{"op": "update", "id": "1", "version": 7, "item": gin.H{"name": "Pen", "stock": 90, "price": 1.60}},
+			{"op": "explode", "id": "2"},
+		},
+	}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusMultiStatus, w.Code)
+
+	var response handlers.BulkResponse
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), 1, response.Succeeded)
+	assert.Equal(suite.T(), 3, response.Failed)
+	assert.Equal(suite.T(), http.StatusBadRequest, response.Results[1].Status)
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, response.Results[2].Status)
+	assert.Equal(suite.T(), http.StatusBadRequest, response.Results[3].Status)
+	assert.Equal(suite.T(), int64(3), suite.countItems())
+}
+
+func TestBulkTestSuite(t *testing.T) {
+	suite.Run(t, new(BulkTestSuite))
+}
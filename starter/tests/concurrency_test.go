@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/models"
+)
+
+type ConcurrencyTestSuite struct {
+	apiSuite
+}
+
+func (suite *ConcurrencyTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+	suite.db.Create(&models.Item{ID: "1", Name: "Shelf", Stock: 8, Price: 60.00})
+}
+
+func (suite *ConcurrencyTestSuite) requestWithIfMatch(method, path string, body interface{}, ifMatch string) *httptest.ResponseRecorder {
+	var reader *bytes.Buffer
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewBuffer(data)
+	} else {
+		reader = &bytes.Buffer{}
+	}
+	req, _ := http.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+suite.jwtToken)
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *ConcurrencyTestSuite) TestStaleIfMatchIsRejected() {
+	w := performRequest(suite.router, "GET", "/api/v1/inventory/1", nil, "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(suite.T(), etag)
+
+	update := gin.H{"name": "Shelf", "stock": 8, "price": 65.00}
+	w = suite.requestWithIfMatch("PUT", "/api/v1/inventory/1", update, etag)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	newETag := w.Header().Get("ETag")
+	assert.NotEqual(suite.T(), etag, newETag)
+
+	w = suite.requestWithIfMatch("PUT", "/api/v1/inventory/1", update, etag)
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+
+	w = suite.requestWithIfMatch("DELETE", "/api/v1/inventory/1", nil, etag)
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+
+	w = suite.requestWithIfMatch("DELETE", "/api/v1/inventory/1", nil, newETag)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *ConcurrencyTestSuite) TestIfMatchIsOptionalByDefault() {
+	update := gin.H{"name": "Shelf", "stock": 9, "price": 60.00}
+	w := suite.requestWithIfMatch("PUT", "/api/v1/inventory/1", update, "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.requestWithIfMatch("DELETE", "/api/v1/inventory/1", nil, "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *ConcurrencyTestSuite) TestIfMatchCanBeRequired() {
+	os.Setenv("REQUIRE_IF_MATCH", "true")
+	defer os.Unsetenv("REQUIRE_IF_MATCH")
+
+	update := gin.H{"name": "Shelf", "stock": 9, "price": 60.00}
+	w := suite.requestWithIfMatch("PUT", "/api/v1/inventory/1", update, "")
+	assert.Equal(suite.T(), http.StatusPreconditionRequired, w.Code)
+
+	w = suite.requestWithIfMatch("DELETE", "/api/v1/inventory/1", nil, "")
+	assert.Equal(suite.T(), http.StatusPreconditionRequired, w.Code)
+
+	w = suite.requestWithIfMatch("PUT", "/api/v1/inventory/1", update, "*")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *ConcurrencyTestSuite) TestEveryWriteIncrementsVersion() {
+	w := performRequest(suite.router, "POST", "/api/v1/inventory/1/stock",
+		gin.H{"delta": 2, "reason": "receipt"}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/inventory/1/tags",
+		gin.H{"tags": []string{"oak"}}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var item models.Item
+	suite.db.First(&item, "id = ?", "1")
+	assert.Equal(suite.T(), 3, item.Version)
+}
+
+func TestConcurrencyTestSuite(t *testing.T) {
+	suite.Run(t, new(ConcurrencyTestSuite))
+}
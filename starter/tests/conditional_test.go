@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/models"
+)
+
+type ConditionalGetTestSuite struct {
+	apiSuite
+}
+
+func (suite *ConditionalGetTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+	suite.db.Create(&models.Item{ID: "1", Name: "Kettle", Stock: 7, Price: 35.00})
+}
+
+func (suite *ConditionalGetTestSuite) get(path string, header, value string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *ConditionalGetTestSuite) TestItemIfNoneMatch() {
+	w := suite.get("/api/v1/inventory/1", "", "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(suite.T(), etag)
+	assert.NotEmpty(suite.T(), w.Header().Get("Last-Modified"))
+
+	w = suite.get("/api/v1/inventory/1", "If-None-Match", etag)
+	assert.Equal(suite.T(), http.StatusNotModified, w.Code)
+	assert.Empty(suite.T(), w.Body.String())
+
+	w = performRequest(suite.router, "PUT", "/api/v1/inventory/1",
+		gin.H{"name": "Kettle", "stock": 7, "price": 30.00}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.get("/api/v1/inventory/1", "If-None-Match", etag)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *ConditionalGetTestSuite) TestListIfNoneMatchAndIfModifiedSince() {
+	w := suite.get("/api/v1/inventory", "", "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+
+	w = suite.get("/api/v1/inventory", "If-None-Match", etag)
+	assert.Equal(suite.T(), http.StatusNotModified, w.Code)
+
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	w = suite.get("/api/v1/inventory", "If-Modified-Since", future)
+	assert.Equal(suite.T(), http.StatusNotModified, w.Code)
+
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	w = suite.get("/api/v1/inventory", "If-Modified-Since", past)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func TestConditionalGetTestSuite(t *testing.T) {
+	suite.Run(t, new(ConditionalGetTestSuite))
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/handlers"
+	"inventory_management/models"
+)
+
+type CursorTestSuite struct {
+	apiSuite
+}
+
+func (suite *CursorTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+
+	items := []models.Item{
+		{ID: "a", Name: "Cable", Stock: 10, Price: 5.00},
+		{ID: "b", Name: "Charger", Stock: 10, Price: 25.00},
+		{ID: "c", Name: "Dock", Stock: 20, Price: 80.00},
+		{ID: "d", Name: "Hub", Stock: 10, Price: 30.00},
+		{ID: "e", Name: "Stand", Stock: 30, Price: 45.00},
+	}
+	suite.db.Create(&items)
+}
+
+func (suite *CursorTestSuite) getPage(path string) handlers.CursorPaginationResponse {
+	w := performRequest(suite.router, "GET", path, nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response handlers.CursorPaginationResponse
+	decodeJSON(suite.T(), w, &response)
+	return response
+}
+
+func itemIDs(items []models.Item) []string {
+	result := make([]string, len(items))
+	for i, item := range items {
+		result[i] = item.ID
+	}
+	return result
+}
+
+func (suite *CursorTestSuite) TestWalkForwardAndBackUsesIDAsTiebreaker() {
+	base := "/api/v1/inventory?sort_by=stock&limit=2"
+
+	page := suite.getPage(base)
+	assert.Equal(suite.T(), []string{"a", "b"}, itemIDs(page.Data))
+	assert.True(suite.T(), page.HasNext)
+	assert.False(suite.T(), page.HasPrev)
+	assert.Nil(suite.T(), page.Total)
+
+	page = suite.getPage(base + "&cursor=" + page.NextCursor)
+	assert.Equal(suite.T(), []string{"d", "c"}, itemIDs(page.Data))
+	assert.True(suite.T(), page.HasPrev)
+
+	last := suite.getPage(base + "&cursor=" + page.NextCursor)
+	assert.Equal(suite.T(), []string{"e"}, itemIDs(last.Data))
+	assert.False(suite.T(), last.HasNext)
+	assert.Empty(suite.T(), last.NextCursor)
+
+	page = suite.getPage(base + "&cursor=" + last.PrevCursor)
+	assert.Equal(suite.T(), []string{"d", "c"}, itemIDs(page.Data))
+	assert.True(suite.T(), page.HasNext)
+	assert.True(suite.T(), page.HasPrev)
+}
+
+func (suite *CursorTestSuite) TestNewItemsDoNotShiftLaterPages() {
+	base := "/api/v1/inventory?sort_by=price&sort_order=desc&limit=2&include_total=true"
+
+	page := suite.getPage(base)
+	assert.Equal(suite.T(), []string{"c", "e"}, itemIDs(page.Data))
+	require.NotNil(suite.T(), page.Total)
+	assert.Equal(suite.T(), int64(5), *page.Total)
+
+	suite.db.Create(&models.Item{ID: "f", Name: "Monitor", Stock: 3, Price: 199.00})
+
+	page = suite.getPage(base + "&cursor=" + page.NextCursor)
+	assert.Equal(suite.T(), []string{"d", "b"}, itemIDs(page.Data))
+}
+
+func (suite *CursorTestSuite) TestInvalidOrMismatchedCursorIsRejected() {
+	page := suite.getPage("/api/v1/inventory?limit=2")
+
+	w := performRequest(suite.router, "GET", "/api/v1/inventory?limit=2&cursor=not-a-cursor", nil, "")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory?limit=2&sort_by=price&cursor="+page.NextCursor, nil, "")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func TestCursorTestSuite(t *testing.T) {
+	suite.Run(t, new(CursorTestSuite))
+}
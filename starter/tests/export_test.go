@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"github.com/xuri/excelize/v2"
+
+	"inventory_management/models"
+)
+
+type ExportTestSuite struct {
+	apiSuite
+}
+
+func (suite *ExportTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+
+	items := []models.Item{
+		{ID: "1", Name: "Laptop", Stock: 10, Price: 999.99},
+		{ID: "2", Name: "Mouse", Stock: 50, Price: 29.99},
+		{ID: "3", Name: "Keyboard", Stock: 5, Price: 79.99},
+		{ID: "4", Name: "Old Monitor", Stock: 2, Price: 99.00, Status: models.ItemStatusArchived},
+	}
+	suite.db.Create(&items)
+	suite.db.Model(&items[1]).Association("Tags").Append(&models.Tag{ID: "t1", Name: "wireless"})
+}
+
+func (suite *ExportTestSuite) TestCSVExportHonoursFiltersAndSort() {
+	w := performRequest(suite.router, "GET", "/api/v1/inventory/export?format=csv&min_stock=5&sort_by=stock&sort_order=desc", nil, "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Header().Get("Content-Type"), "text/csv")
+	assert.Contains(suite.T(), w.Header().Get("Content-Disposition"), "attachment")
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), records, 4)
+	assert.Equal(suite.T(), "id", records[0][0])
+	assert.Equal(suite.T(), "Mouse", records[1][2])
+	assert.Equal(suite.T(), "wireless", records[1][6])
+	assert.Equal(suite.T(), "Laptop", records[2][2])
+	assert.Equal(suite.T(), "Keyboard", records[3][2])
+}
+
+func (suite *ExportTestSuite) TestNDJSONExportWritesOneItemPerLine() {
+	w := performRequest(suite.router, "GET", "/api/v1/inventory/export?format=ndjson&status=all", nil, "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "application/x-ndjson", w.Header().Get("Content-Type"))
+
+	var names []string
+	scanner := bufio.NewScanner(strings.NewReader(w.Body.String()))
+	for scanner.Scan() {
+		var item models.Item
+		require.NoError(suite.T(), json.Unmarshal(scanner.Bytes(), &item))
+		names = append(names, item.Name)
+	}
+	assert.Equal(suite.T(), []string{"Keyboard", "Laptop", "Mouse", "Old Monitor"}, names)
+}
+
+func (suite *ExportTestSuite) TestXLSXExport() {
+	w := performRequest(suite.router, "GET", "/api/v1/inventory/export?format=xlsx&name=o", nil, "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	file, err := excelize.OpenReader(bytes.NewReader(w.Body.Bytes()))
+	require.NoError(suite.T(), err)
+	defer file.Close()
+
+	rows, err := file.GetRows(file.GetSheetName(0))
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), rows, 4)
+	assert.Equal(suite.T(), "Keyboard", rows[1][2])
+	assert.Equal(suite.T(), "5", rows[1][3])
+}
+
+func (suite *ExportTestSuite) TestExportRejectsUnknownFormat() {
+	w := performRequest(suite.router, "GET", "/api/v1/inventory/export?format=pdf", nil, "")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory/export?include_deleted=true", nil, "")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func TestExportTestSuite(t *testing.T) {
+	suite.Run(t, new(ExportTestSuite))
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/filter"
+	"inventory_management/handlers"
+	"inventory_management/models"
+)
+
+type FilterTestSuite struct {
+	apiSuite
+}
+
+func (suite *FilterTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+
+	items := []models.Item{
+		{ID: "1", Name: "Laptop", Stock: 3, Price: 999.99},
+		{ID: "2", Name: "USB Cable", Stock: 50, Price: 9.99},
+		{ID: "3", Name: "Monitor", Stock: 12, Price: 299.99},
+		{ID: "4", Name: "usb_hub 100%", Stock: 2, Price: 19.99},
+		{ID: "5", Name: "Old Printer", Stock: 1, Price: 149.99, Status: models.ItemStatusDiscontinued},
+	}
+	suite.db.Create(&items)
+}
+
+func (suite *FilterTestSuite) list(expression string, extra string) (int, handlers.PaginationResponse) {
+	w := performRequest(suite.router, "GET", "/api/v1/inventory?sort_by=name&filter="+url.QueryEscape(expression)+extra, nil, "")
+	var response handlers.PaginationResponse
+	if w.Code == http.StatusOK {
+		decodeJSON(suite.T(), w, &response)
+	}
+	return w.Code, response
+}
+
+func (suite *FilterTestSuite) TestAndBindsTighterThanOr() {
+	code, response := suite.list("price gte 100 and stock lt 5 or name contains 'usb'", "")
+	require.Equal(suite.T(), http.StatusOK, code)
+	assert.Equal(suite.T(), []string{"1", "2", "4"}, itemIDs(response.Data))
+
+	code, response = suite.list("price gte 100 and (stock lt 5 or name contains 'usb')", "")
+	require.Equal(suite.T(), http.StatusOK, code)
+	assert.Equal(suite.T(), []string{"1"}, itemIDs(response.Data))
+}
+
+func (suite *FilterTestSuite) TestInNotAndLikeWildcardsAreLiteral() {
+	code, response := suite.list("status in ('active', 'discontinued') and not stock gt 5", "&status=all")
+	require.Equal(suite.T(), http.StatusOK, code)
+	assert.Equal(suite.T(), []string{"1", "5", "4"}, itemIDs(response.Data))
+
+	code, response = suite.list("name endswith '100%'", "")
+	require.Equal(suite.T(), http.StatusOK, code)
+	assert.Equal(suite.T(), []string{"4"}, itemIDs(response.Data))
+
+	code, response = suite.list("name contains '_'", "")
+	require.Equal(suite.T(), http.StatusOK, code)
+	assert.Equal(suite.T(), []string{"4"}, itemIDs(response.Data))
+}
+
+func (suite *FilterTestSuite) TestInvalidFilterReturnsBadRequest() {
+	w := performRequest(suite.router, "GET", "/api/v1/inventory?filter="+url.QueryEscape("colour eq 'red'"), nil, "")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	var body map[string]string
+	decodeJSON(suite.T(), w, &body)
+	assert.Equal(suite.T(), "Invalid filter at position 1 near 'colour': unknown field", body["error"])
+}
+
+func (suite *FilterTestSuite) TestErrorsPointAtTheOffendingToken() {
+	fields := map[string]filter.Field{
+		"name":  {Column: "name", Type: filter.String},
+		"stock": {Column: "stock", Type: filter.Int},
+	}
+	cases := map[string]int{
+		"stock lt 'five'":               10,
+		"stock between 1":               7,
+		"name contains 'usb' and":       24,
+		"(stock gt 1":                   12,
+		"name eq 'a'; drop table items": 12,
+		"stock gt 1.5":                  10,
+		"name gt 'x' stock lt 1":        13,
+	}
+	for input, pos := range cases {
+		_, err := filter.Parse(input, fields)
+		var filterErr *filter.Error
+		if assert.ErrorAs(suite.T(), err, &filterErr, input) {
+			assert.Equal(suite.T(), pos, filterErr.Pos, input)
+		}
+	}
+
+	node, err := filter.Parse("name contains 'o''brien' or stock in (1, 2)", fields)
+	require.NoError(suite.T(), err)
+	condition, args := filter.SQL(node)
+	assert.Equal(suite.T(), `(LOWER(name) LIKE ? ESCAPE '\' OR stock IN ?)`, condition)
+	assert.Equal(suite.T(), []interface{}{"%o'brien%", []interface{}{int64(1), int64(2)}}, args)
+}
+
+func TestFilterTestSuite(t *testing.T) {
+	suite.Run(t, new(FilterTestSuite))
+}
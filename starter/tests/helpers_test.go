@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"inventory_management/database"
+	"inventory_management/middleware"
+	"inventory_management/models"
+	"inventory_management/routes"
+)
+
+// apiSuite is embedded by the feature suites. Each suite gets its own
+// database, named after the suite, and an admin token; every test starts
+// without items and with a fresh router. Suites that need more call these
+// methods from their own setup first.
+type apiSuite struct {
+	suite.Suite
+	router   *gin.Engine
+	db       *gorm.DB
+	jwtToken string
+}
+
+func (suite *apiSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+	suite.db = openTestDB(suite.T(), suite.T().Name())
+
+	token, err := middleware.GenerateJWT("admin", models.RoleAdmin)
+	require.NoError(suite.T(), err)
+	suite.jwtToken = token
+}
+
+func (suite *apiSuite) SetupTest() {
+	suite.db.Unscoped().Where("1 = 1").Delete(&models.Item{})
+	suite.router = routes.SetupRoutes()
+}
+
+func openTestDB(t *testing.T, name string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file:"+name+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(database.TenantPlugin{}))
+	require.NoError(t, database.Migrate(db))
+	require.NoError(t, database.SeedAdminUser(db))
+	database.DB = db
+	return db
+}
+
+func performRequest(router *gin.Engine, method, path string, body interface{}, token string) *httptest.ResponseRecorder {
+	var reader *bytes.Buffer
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewBuffer(data)
+	} else {
+		reader = &bytes.Buffer{}
+	}
+
+	req, _ := http.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func decodeJSON(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), v))
+}
@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"github.com/xuri/excelize/v2"
+
+	"inventory_management/handlers"
+	"inventory_management/models"
+)
+
+type ImportTestSuite struct {
+	apiSuite
+}
+
+func (suite *ImportTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+
+	sku := "PEN-1"
+	suite.db.Create(&models.Item{ID: "1", SKU: &sku, Name: "Pen", Stock: 100, Price: 1.50})
+}
+
+func (suite *ImportTestSuite) upload(query, filename string, data []byte) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", filename)
+	part.Write(data)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/api/v1/inventory/import"+query, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+suite.jwtToken)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *ImportTestSuite) TestCSVImportUpsertsBySKU() {
+	csv := "sku,name,stock,price\n" +
+		"PEN-1,Pen,120,1.50\n" +
+		"ERA-1,Eraser,40,0.50\n" +
+		"BAD-1,Broken,lots,1.00\n"
+
+	w := suite.upload("", "items.csv", []byte(csv))
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response handlers.ImportResponse
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), 3, response.Summary.Rows)
+	assert.Equal(suite.T(), 1, response.Summary.Created)
+	assert.Equal(suite.T(), 1, response.Summary.Updated)
+	assert.Equal(suite.T(), 1, response.Summary.Failed)
+	require.Len(suite.T(), response.Errors, 1)
+	assert.Equal(suite.T(), 4, response.Errors[0].Row)
+	assert.Equal(suite.T(), "stock", response.Errors[0].Column)
+
+	var pen models.Item
+	suite.db.First(&pen, "id = ?", "1")
+	assert.Equal(suite.T(), 120, pen.Stock)
+	assert.Equal(suite.T(), 2, pen.Version)
+
+	var eraser models.Item
+	assert.NoError(suite.T(), suite.db.First(&eraser, "sku = ?", "ERA-1").Error)
+	assert.Equal(suite.T(), "Eraser", eraser.Name)
+}
+
+func (suite *ImportTestSuite) TestSoldOutRowsAreImported() {
+	csv := "sku,name,stock,price\n" +
+		"PEN-1,Pen,0,1.50\n" +
+		"ERA-1,Eraser,,0.50\n"
+
+	w := suite.upload("", "items.csv", []byte(csv))
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var response handlers.ImportResponse
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), 1, response.Summary.Updated)
+	require.Len(suite.T(), response.Errors, 1)
+	assert.Equal(suite.T(), "stock", response.Errors[0].Column)
+
+	var pen models.Item
+	suite.db.First(&pen, "id = ?", "1")
+	assert.Equal(suite.T(), 0, pen.Stock)
+}
+
+func (suite *ImportTestSuite) TestDryRunReportsChangesWithoutWriting() {
+	csv := "sku,name,stock,price,status\n" +
+		"PEN-1,Pen,100,2.00,\n" +
+		"ERA-1,Eraser,40,0.50,retired\n" +
+		"ERA-1,Eraser,40,0.50,\n"
+
+	w := suite.upload("?dry_run=true", "items.csv", []byte(csv))
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response handlers.ImportResponse
+	decodeJSON(suite.T(), w, &response)
+	assert.True(suite.T(), response.DryRun)
+	assert.Equal(suite.T(), 1, response.Summary.Updated)
+	assert.Equal(suite.T(), 1, response.Summary.Created)
+	assert.Equal(suite.T(), 1, response.Summary.Failed)
+	require.Len(suite.T(), response.Changes, 2)
+	assert.Equal(suite.T(), "update", response.Changes[0].Action)
+	assert.Equal(suite.T(), 2.0, response.Changes[0].Changes["price"].To)
+	assert.NotContains(suite.T(), response.Changes[0].Changes, "stock")
+
+	var pen models.Item
+	suite.db.First(&pen, "id = ?", "1")
+	assert.Equal(suite.T(), 1.50, pen.Price)
+	var count int64
+	suite.db.Model(&models.Item{}).Count(&count)
+	assert.Equal(suite.T(), int64(1), count)
+}
+
+func (suite *ImportTestSuite) TestXLSXImportWithColumnMapping() {
+	file := excelize.NewFile()
+	sheet := file.GetSheetName(0)
+	file.SetSheetRow(sheet, "A1", &[]interface{}{"Code", "Product", "Qty", "Unit Price"})
+	file.SetSheetRow(sheet, "A2", &[]interface{}{"RUL-1", "Ruler", 15, 2.25})
+	buf, err := file.WriteToBuffer()
+	require.NoError(suite.T(), err)
+
+	mapping := url.QueryEscape(`{"sku":"Code","name":"Product","stock":"Qty","price":"Unit Price"}`)
+	w := suite.upload("?mapping="+mapping, "items.xlsx", buf.Bytes())
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var ruler models.Item
+	assert.NoError(suite.T(), suite.db.First(&ruler, "sku = ?", "RUL-1").Error)
+	assert.Equal(suite.T(), 15, ruler.Stock)
+	assert.Equal(suite.T(), 2.25, ruler.Price)
+}
+
+func (suite *ImportTestSuite) TestXLSXImportHasALowerSizeCap() {
+	w := suite.upload("", "items.xlsx", make([]byte, 10<<20+1))
+	assert.Equal(suite.T(), http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "10 MB")
+}
+
+func (suite *ImportTestSuite) TestImportRejectsMissingColumnsAndUnknownFormats() {
+	w := suite.upload("", "items.csv", []byte("sku,name,price\nPEN-1,Pen,1.50\n"))
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.upload("", "items.txt", []byte("sku,name,stock,price\n"))
+	assert.Equal(suite.T(), http.StatusUnsupportedMediaType, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/inventory/import", nil, "")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func TestImportTestSuite(t *testing.T) {
+	suite.Run(t, new(ImportTestSuite))
+}
@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/middleware"
+	"inventory_management/models"
+)
+
+type JWKSTestSuite struct {
+	apiSuite
+	dir string
+}
+
+type jwksResponse struct {
+	Keys []middleware.JSONWebKey `json:"keys"`
+}
+
+func (suite *JWKSTestSuite) SetupTest() {
+	suite.dir = suite.T().TempDir()
+	os.Setenv("JWT_KEYS_DIR", suite.dir)
+	suite.apiSuite.SetupTest()
+}
+
+func (suite *JWKSTestSuite) TearDownTest() {
+	os.Unsetenv("JWT_SIGNING_ALG")
+	os.Unsetenv("JWT_KEYS_DIR")
+	require.NoError(suite.T(), middleware.ReloadSigningKeys())
+}
+
+func (suite *JWKSTestSuite) useAlgorithm(alg string) {
+	os.Setenv("JWT_SIGNING_ALG", alg)
+	require.NoError(suite.T(), middleware.ReloadSigningKeys())
+}
+
+func (suite *JWKSTestSuite) jwks() []middleware.JSONWebKey {
+	w := performRequest(suite.router, "GET", "/.well-known/jwks.json", nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var body jwksResponse
+	decodeJSON(suite.T(), w, &body)
+	return body.Keys
+}
+
+func tokenHeader(t *testing.T, token string) map[string]string {
+	data, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+	require.NoError(t, err)
+	var header map[string]string
+	require.NoError(t, json.Unmarshal(data, &header))
+	return header
+}
+
+func (suite *JWKSTestSuite) TestHS256PublishesNoKeys() {
+	token, err := middleware.GenerateJWT("admin", models.RoleAdmin)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "HS256", tokenHeader(suite.T(), token)["alg"])
+	assert.Empty(suite.T(), suite.jwks())
+}
+
+func (suite *JWKSTestSuite) TestEdDSAKeyIsGeneratedAndPublished() {
+	suite.useAlgorithm("EdDSA")
+
+	token, err := middleware.GenerateJWT("admin", models.RoleAdmin)
+	require.NoError(suite.T(), err)
+	header := tokenHeader(suite.T(), token)
+	assert.Equal(suite.T(), "EdDSA", header["alg"])
+
+	keys := suite.jwks()
+	require.Len(suite.T(), keys, 1)
+	assert.Equal(suite.T(), header["kid"], keys[0].Kid)
+	assert.Equal(suite.T(), "OKP", keys[0].Kty)
+	assert.Equal(suite.T(), "Ed25519", keys[0].Crv)
+	assert.NotEmpty(suite.T(), keys[0].X)
+
+	files, _ := filepath.Glob(filepath.Join(suite.dir, "*.pem"))
+	assert.Len(suite.T(), files, 1)
+
+	w := performRequest(suite.router, "GET", "/api/v1/users", nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *JWKSTestSuite) TestRS256TokensVerify() {
+	suite.useAlgorithm("RS256")
+
+	token, err := middleware.GenerateJWT("admin", models.RoleAdmin)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "RS256", tokenHeader(suite.T(), token)["alg"])
+
+	keys := suite.jwks()
+	require.Len(suite.T(), keys, 1)
+	assert.Equal(suite.T(), "RSA", keys[0].Kty)
+	assert.Equal(suite.T(), "AQAB", keys[0].E)
+
+	w := performRequest(suite.router, "GET", "/api/v1/users", nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *JWKSTestSuite) TestRotationPublishesTheNextKeyFirst() {
+	suite.useAlgorithm("EdDSA")
+	oldKid := suite.backdate(suite.onlyKid(), 100*time.Hour)
+	require.NoError(suite.T(), middleware.ReloadSigningKeys())
+	oldToken, err := middleware.GenerateJWT("admin", models.RoleAdmin)
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), middleware.RotateSigningKey())
+
+	// The new key is published but does not sign yet.
+	keys := suite.jwks()
+	require.Len(suite.T(), keys, 2)
+	newKid := keys[0].Kid
+	assert.NotEqual(suite.T(), oldKid, newKid)
+	token, err := middleware.GenerateJWT("admin", models.RoleAdmin)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), oldKid, tokenHeader(suite.T(), token)["kid"])
+
+	// After one overlap it signs, and the old key still verifies.
+	newKid = suite.backdate(newKid, 25*time.Hour)
+	require.NoError(suite.T(), middleware.ReloadSigningKeys())
+	newToken, err := middleware.GenerateJWT("admin", models.RoleAdmin)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), newKid, tokenHeader(suite.T(), newToken)["kid"])
+	w := performRequest(suite.router, "GET", "/api/v1/users", nil, oldToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	// After a second overlap the old key is retired.
+	suite.backdate(newKid, 49*time.Hour)
+	require.NoError(suite.T(), middleware.ReloadSigningKeys())
+	w = performRequest(suite.router, "GET", "/api/v1/users", nil, oldToken)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *JWKSTestSuite) TestKeysAddedByAnotherInstanceAreLoadedOnDemand() {
+	suite.useAlgorithm("EdDSA")
+	suite.backdate(suite.onlyKid(), time.Hour)
+	require.NoError(suite.T(), middleware.ReloadSigningKeys())
+
+	// Another instance sharing the directory signs with a key of its own.
+	other := suite.T().TempDir()
+	os.Setenv("JWT_KEYS_DIR", other)
+	require.NoError(suite.T(), middleware.ReloadSigningKeys())
+	token, err := middleware.GenerateJWT("admin", models.RoleAdmin)
+	require.NoError(suite.T(), err)
+	kid := tokenHeader(suite.T(), token)["kid"]
+
+	os.Setenv("JWT_KEYS_DIR", suite.dir)
+	require.NoError(suite.T(), middleware.ReloadSigningKeys())
+	data, err := os.ReadFile(filepath.Join(other, kid+".pem"))
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), os.WriteFile(filepath.Join(suite.dir, kid+".pem"), data, 0o600))
+
+	w := performRequest(suite.router, "GET", "/api/v1/users", nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *JWKSTestSuite) TestKeyAgeComesFromTheKidNotTheFile() {
+	suite.useAlgorithm("EdDSA")
+	oldKid := suite.backdate(suite.onlyKid(), 48*time.Hour)
+	require.NoError(suite.T(), middleware.RotateSigningKey())
+
+	// Touching the old key does not make it the newest.
+	now := time.Now()
+	require.NoError(suite.T(), os.Chtimes(filepath.Join(suite.dir, oldKid+".pem"), now.Add(time.Hour), now.Add(time.Hour)))
+	require.NoError(suite.T(), middleware.ReloadSigningKeys())
+
+	keys := suite.jwks()
+	require.Len(suite.T(), keys, 2)
+	assert.NotEqual(suite.T(), oldKid, keys[0].Kid)
+	assert.Equal(suite.T(), oldKid, keys[1].Kid)
+}
+
+func (suite *JWKSTestSuite) onlyKid() string {
+	files, err := filepath.Glob(filepath.Join(suite.dir, "*.pem"))
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), files, 1)
+	return strings.TrimSuffix(filepath.Base(files[0]), ".pem")
+}
+
+// backdate renames the key kid as if it had been created age ago and
+// returns its new kid.
+func (suite *JWKSTestSuite) backdate(kid string, age time.Duration) string {
+	_, suffix, _ := strings.Cut(kid, "-")
+	renamed := time.Now().Add(-age).UTC().Format("20060102T150405Z") + "-" + suffix
+	require.NoError(suite.T(), os.Rename(filepath.Join(suite.dir, kid+".pem"), filepath.Join(suite.dir, renamed+".pem")))
+	return renamed
+}
+
+func TestJWKSTestSuite(t *testing.T) {
+	suite.Run(t, new(JWKSTestSuite))
+}
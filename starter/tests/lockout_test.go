@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/database"
+	"inventory_management/models"
+	"inventory_management/routes"
+)
+
+type LockoutTestSuite struct {
+	apiSuite
+}
+
+func (suite *LockoutTestSuite) SetupSuite() {
+	suite.apiSuite.SetupSuite()
+	os.Setenv("LOGIN_MAX_FAILURES", "2")
+	os.Setenv("LOGIN_MAX_FAILURES_PER_IP", "3")
+	os.Setenv("LOGIN_LOCKOUT", "1m")
+}
+
+func (suite *LockoutTestSuite) TearDownSuite() {
+	for _, key := range []string{"LOGIN_MAX_FAILURES", "LOGIN_MAX_FAILURES_PER_IP", "LOGIN_LOCKOUT"} {
+		os.Unsetenv(key)
+	}
+}
+
+// login sends each test's attempts from its own client IP so the per-IP
+// counters of different tests do not interfere.
+func (suite *LockoutTestSuite) login(username, password, clientIP string) *httptest.ResponseRecorder {
+	return suite.loginVia(username, password, clientIP, "")
+}
+
+// loginVia sends a login from clientIP with an X-Forwarded-For header
+// claiming to come from forwardedFor.
+func (suite *LockoutTestSuite) loginVia(username, password, clientIP, forwardedFor string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(gin.H{"username": username, "password": password})
+	req := httptest.NewRequest("POST", "/api/v1/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = clientIP + ":40000"
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *LockoutTestSuite) TestRepeatedFailuresLockTheAccount() {
+	user := models.User{ID: "locked-id", Username: "locked"}
+	require.NoError(suite.T(), user.SetPassword("correct-password"))
+	require.NoError(suite.T(), suite.db.Create(&user).Error)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, suite.login("locked", "wrong-password", "198.51.100.1").Code)
+	assert.Equal(suite.T(), http.StatusUnauthorized, suite.login("locked", "wrong-password", "198.51.100.2").Code)
+
+	// Even the right password is refused while the lock lasts.
+	w := suite.login("locked", "correct-password", "198.51.100.3")
+	require.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+	var response struct {
+		RetryAfter int `json:"retry_after"`
+	}
+	decodeJSON(suite.T(), w, &response)
+	assert.InDelta(suite.T(), 60, response.RetryAfter, 1)
+	assert.Equal(suite.T(), "60", w.Header().Get("Retry-After"))
+
+	var entry models.AuditLog
+	require.NoError(suite.T(), suite.db.Where("action = ? AND actor = ?", "lockout", "locked").First(&entry).Error)
+	assert.Contains(suite.T(), string(entry.After), `"counter":"user:locked"`)
+}
+
+func (suite *LockoutTestSuite) TestUnknownUsernamesAreLockedTheSameWay() {
+	assert.Equal(suite.T(), http.StatusUnauthorized, suite.login("ghost", "whatever-1", "198.51.100.10").Code)
+	assert.Equal(suite.T(), http.StatusUnauthorized, suite.login("Ghost", "whatever-2", "198.51.100.11").Code)
+	assert.Equal(suite.T(), http.StatusTooManyRequests, suite.login("ghost", "whatever-3", "198.51.100.12").Code)
+}
+
+func (suite *LockoutTestSuite) TestSuccessfulLoginResetsTheCounter() {
+	assert.Equal(suite.T(), http.StatusUnauthorized, suite.login("admin", "wrong-password", "198.51.100.20").Code)
+	assert.Equal(suite.T(), http.StatusOK, suite.login("admin", "password", "198.51.100.21").Code)
+	assert.Equal(suite.T(), http.StatusUnauthorized, suite.login("admin", "wrong-password", "198.51.100.22").Code)
+	assert.Equal(suite.T(), http.StatusOK, suite.login("admin", "password", "198.51.100.23").Code)
+}
+
+func (suite *LockoutTestSuite) TestOneClientSprayingUsernamesIsLocked() {
+	for _, username := range []string{"alice", "bob", "carol"} {
+		assert.Equal(suite.T(), http.StatusUnauthorized, suite.login(username, "password", "203.0.113.7").Code)
+	}
+	assert.Equal(suite.T(), http.StatusTooManyRequests, suite.login("admin", "password", "203.0.113.7").Code)
+	assert.Equal(suite.T(), http.StatusOK, suite.login("admin", "password", "203.0.113.8").Code)
+}
+
+func (suite *LockoutTestSuite) TestForgedForwardedForDoesNotResetTheIPCounter() {
+	for i, username := range []string{"dave", "erin", "frank"} {
+		forged := fmt.Sprintf("10.0.0.%d", i+1)
+		assert.Equal(suite.T(), http.StatusUnauthorized, suite.loginVia(username, "password", "203.0.113.30", forged).Code)
+	}
+	assert.Equal(suite.T(), http.StatusTooManyRequests, suite.loginVia("admin", "password", "203.0.113.30", "10.0.0.99").Code)
+}
+
+func (suite *LockoutTestSuite) TestForwardedForIsUsedBehindATrustedProxy() {
+	os.Setenv("TRUSTED_PROXIES", "192.0.2.0/24")
+	defer os.Unsetenv("TRUSTED_PROXIES")
+	suite.router = routes.SetupRoutes()
+
+	for _, username := range []string{"grace", "heidi", "ivan"} {
+		assert.Equal(suite.T(), http.StatusUnauthorized, suite.loginVia(username, "password", "192.0.2.10", "203.0.113.40").Code)
+	}
+	assert.Equal(suite.T(), http.StatusTooManyRequests, suite.loginVia("admin", "password", "192.0.2.11", "203.0.113.40").Code)
+	assert.Equal(suite.T(), http.StatusOK, suite.loginVia("admin", "password", "192.0.2.10", "203.0.113.41").Code)
+}
+
+func (suite *LockoutTestSuite) TestBackoffDoublesUpToTheMaximum() {
+	assert.Equal(suite.T(), time.Duration(0), database.LoginBackoff(1, 2))
+	assert.Equal(suite.T(), time.Minute, database.LoginBackoff(2, 2))
+	assert.Equal(suite.T(), 2*time.Minute, database.LoginBackoff(3, 2))
+	assert.Equal(suite.T(), 8*time.Minute, database.LoginBackoff(5, 2))
+	assert.Equal(suite.T(), 15*time.Minute, database.LoginBackoff(50, 2))
+}
+
+func TestLockoutTestSuite(t *testing.T) {
+	suite.Run(t, new(LockoutTestSuite))
+}
@@ -0,0 +1,208 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/database"
+	"inventory_management/middleware"
+	"inventory_management/models"
+)
+
+type MFATestSuite struct {
+	apiSuite
+}
+
+type mfaLoginResponse struct {
+	MFARequired  bool   `json:"mfa_required"`
+	MFAToken     string `json:"mfa_token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// createMFAUser creates a user who has already enrolled a second factor.
+func (suite *MFATestSuite) createMFAUser(username string, role models.Role) models.User {
+	user := models.User{ID: username + "-id", Username: username, Role: role, MFAEnabled: true}
+	require.NoError(suite.T(), user.SetPassword("a-long-password"))
+	_, err := user.GenerateTOTPSecret("test")
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), suite.db.Create(&user).Error)
+	return user
+}
+
+func (suite *MFATestSuite) startLogin(username string) string {
+	w := performRequest(suite.router, "POST", "/api/v1/login", gin.H{"username": username, "password": "a-long-password"}, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response mfaLoginResponse
+	decodeJSON(suite.T(), w, &response)
+	require.True(suite.T(), response.MFARequired)
+	require.Empty(suite.T(), response.Token)
+	return response.MFAToken
+}
+
+func (suite *MFATestSuite) code(user models.User, at time.Time) string {
+	code, err := totp.GenerateCode(user.MFASecret, at)
+	require.NoError(suite.T(), err)
+	return code
+}
+
+func (suite *MFATestSuite) TestEnrolment() {
+	user := models.User{ID: "enrolling-id", Username: "enrolling", Role: models.RoleManager}
+	require.NoError(suite.T(), user.SetPassword("a-long-password"))
+	require.NoError(suite.T(), suite.db.Create(&user).Error)
+	token, err := middleware.GenerateJWT("enrolling", models.RoleManager)
+	require.NoError(suite.T(), err)
+
+	w := performRequest(suite.router, "POST", "/api/v1/mfa/enroll", nil, token)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var enrolment struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauth_uri"`
+		QRPNG      string `json:"qr_png"`
+	}
+	decodeJSON(suite.T(), w, &enrolment)
+	assert.True(suite.T(), strings.HasPrefix(enrolment.OTPAuthURI, "otpauth://totp/"))
+	assert.Contains(suite.T(), enrolment.OTPAuthURI, "secret="+enrolment.Secret)
+	qr, err := base64.StdEncoding.DecodeString(enrolment.QRPNG)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), bytes.HasPrefix(qr, []byte("\x89PNG")))
+
+	w = performRequest(suite.router, "POST", "/api/v1/mfa/verify", gin.H{"code": "000000"}, token)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	code, err := totp.GenerateCode(enrolment.Secret, time.Now())
+	require.NoError(suite.T(), err)
+	w = performRequest(suite.router, "POST", "/api/v1/mfa/verify", gin.H{"code": code}, token)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var verified struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	decodeJSON(suite.T(), w, &verified)
+	assert.Len(suite.T(), verified.RecoveryCodes, 10)
+
+	require.NoError(suite.T(), suite.db.First(&user, "id = ?", user.ID).Error)
+	assert.True(suite.T(), user.MFAEnabled)
+
+	w = performRequest(suite.router, "POST", "/api/v1/mfa/enroll", nil, token)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *MFATestSuite) TestLoginNeedsTheSecondFactor() {
+	user := suite.createMFAUser("two-step", models.RoleManager)
+	challenge := suite.startLogin("two-step")
+
+	// The challenge is no access token.
+	w := performRequest(suite.router, "GET", "/api/v1/audit", nil, challenge)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/login/mfa", gin.H{"mfa_token": challenge, "code": suite.code(user, time.Now())}, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response mfaLoginResponse
+	decodeJSON(suite.T(), w, &response)
+	assert.NotEmpty(suite.T(), response.Token)
+
+	// Each challenge completes one login only.
+	w = performRequest(suite.router, "POST", "/api/v1/login/mfa", gin.H{"mfa_token": challenge, "code": suite.code(user, time.Now().Add(30*time.Second))}, "")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *MFATestSuite) TestCodesCannotBeReplayed() {
+	user := suite.createMFAUser("replayed", models.RoleClerk)
+	now := time.Now()
+	require.NoError(suite.T(), suite.db.Model(&user).Update("mfa_last_step", now.Unix()/30).Error)
+	challenge := suite.startLogin("replayed")
+
+	w := performRequest(suite.router, "POST", "/api/v1/login/mfa", gin.H{"mfa_token": challenge, "code": suite.code(user, now)}, "")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/login/mfa", gin.H{"mfa_token": challenge, "code": suite.code(user, now.Add(30*time.Second))}, "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+}
+
+func (suite *MFATestSuite) TestRecoveryCodesWorkOnce() {
+	user := suite.createMFAUser("recovering", models.RoleViewer)
+	codes, err := database.CreateRecoveryCodes(suite.db, user.ID)
+	require.NoError(suite.T(), err)
+
+	challenge := suite.startLogin("recovering")
+	w := performRequest(suite.router, "POST", "/api/v1/login/mfa", gin.H{"mfa_token": challenge, "recovery_code": strings.ToUpper(codes[0])}, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	challenge = suite.startLogin("recovering")
+	w = performRequest(suite.router, "POST", "/api/v1/login/mfa", gin.H{"mfa_token": challenge, "recovery_code": codes[0]}, "")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *MFATestSuite) TestGuessingCodesWithASessionIsLockedOut() {
+	os.Setenv("LOGIN_MAX_FAILURES", "2")
+	defer os.Unsetenv("LOGIN_MAX_FAILURES")
+
+	user := suite.createMFAUser("guessed", models.RoleClerk)
+	token, err := middleware.GenerateStepUpJWT("guessed", models.RoleClerk)
+	require.NoError(suite.T(), err)
+
+	for _, guess := range []string{"000000", "111111"} {
+		w := performRequest(suite.router, "DELETE", "/api/v1/mfa", gin.H{"code": guess}, token)
+		assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	}
+	w := performRequest(suite.router, "DELETE", "/api/v1/mfa", gin.H{"code": suite.code(user, time.Now())}, token)
+	assert.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+
+	require.NoError(suite.T(), suite.db.First(&user, "id = ?", user.ID).Error)
+	assert.True(suite.T(), user.MFAEnabled)
+}
+
+func (suite *MFATestSuite) TestPolicyRequiresMFAForAdminWrites() {
+	os.Setenv("REQUIRE_MFA_FOR_ADMINS", "true")
+	defer os.Unsetenv("REQUIRE_MFA_FOR_ADMINS")
+
+	plain, err := middleware.GenerateJWT("admin", models.RoleAdmin)
+	require.NoError(suite.T(), err)
+	stepUp, err := middleware.GenerateStepUpJWT("admin", models.RoleAdmin)
+	require.NoError(suite.T(), err)
+	item := gin.H{"name": "Scanner", "stock": 2, "price": 99.00}
+
+	w := performRequest(suite.router, "POST", "/api/v1/inventory", item, plain)
+	require.Equal(suite.T(), http.StatusForbidden, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"mfa_required":true`)
+
+	w = performRequest(suite.router, "GET", "/api/v1/users", nil, plain)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/inventory", item, stepUp)
+	assert.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+}
+
+func (suite *MFATestSuite) TestRefreshedTokensKeepTheSecondFactor() {
+	os.Setenv("REQUIRE_MFA_FOR_ADMINS", "true")
+	defer os.Unsetenv("REQUIRE_MFA_FOR_ADMINS")
+
+	user := suite.createMFAUser("second-admin", models.RoleAdmin)
+	challenge := suite.startLogin("second-admin")
+	w := performRequest(suite.router, "POST", "/api/v1/login/mfa", gin.H{"mfa_token": challenge, "code": suite.code(user, time.Now())}, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response mfaLoginResponse
+	decodeJSON(suite.T(), w, &response)
+
+	w = performRequest(suite.router, "POST", "/api/v1/token/refresh", gin.H{"refresh_token": response.RefreshToken}, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	decodeJSON(suite.T(), w, &response)
+
+	w = performRequest(suite.router, "POST", "/api/v1/inventory", gin.H{"name": "Label printer", "stock": 1, "price": 150.00}, response.Token)
+	assert.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+}
+
+func TestMFATestSuite(t *testing.T) {
+	suite.Run(t, new(MFATestSuite))
+}
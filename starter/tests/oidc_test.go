@@ -0,0 +1,256 @@
+package tests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/models"
+)
+
+// mockIdP is a minimal OpenID provider: discovery, JWKS and a token
+// endpoint that checks the PKCE verifier.
+type mockIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+type mockAuthorization struct {
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	idp := &mockIdP{key: key, codes: make(map[string]mockAuthorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                idp.server.URL,
+			"authorization_endpoint":                idp.server.URL + "/authorize",
+			"token_endpoint":                        idp.server.URL + "/token",
+			"jwks_uri":                              idp.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "idp-key", "use": "sig", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		idp.mu.Lock()
+		auth, ok := idp.codes[r.PostForm.Get("code")]
+		delete(idp.codes, r.PostForm.Get("code"))
+		idp.mu.Unlock()
+
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "invalid_grant"}`))
+			return
+		}
+
+		claims := jwt.MapClaims{"nonce": auth.nonce}
+		for k, v := range auth.claims {
+			claims[k] = v
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "opaque",
+			"token_type":   "Bearer",
+			"id_token":     idp.sign(t, claims),
+		})
+	})
+	idp.server = httptest.NewServer(mux)
+	return idp
+}
+
+// sign issues a token for the test client with the given claims on top of
+// valid defaults.
+func (idp *mockIdP) sign(t *testing.T, extra jwt.MapClaims) string {
+	claims := jwt.MapClaims{
+		"iss": idp.server.URL,
+		"aud": "inventory",
+		"sub": "user-123",
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "idp-key"
+	signed, err := token.SignedString(idp.key)
+	require.NoError(t, err)
+	return signed
+}
+
+// authorize plays the user approving the login at the IdP and returns the
+// code the IdP would redirect back with.
+func (idp *mockIdP) authorize(location *url.URL, claims jwt.MapClaims) string {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	code := "code-" + location.Query().Get("state")[:8]
+	idp.codes[code] = mockAuthorization{
+		challenge: location.Query().Get("code_challenge"),
+		nonce:     location.Query().Get("nonce"),
+		claims:    claims,
+	}
+	return code
+}
+
+type OIDCTestSuite struct {
+	apiSuite
+	idp *mockIdP
+}
+
+func (suite *OIDCTestSuite) SetupSuite() {
+	suite.apiSuite.SetupSuite()
+	suite.idp = newMockIdP(suite.T())
+
+	os.Setenv("OIDC_ISSUER_URL", suite.idp.server.URL)
+	os.Setenv("OIDC_CLIENT_ID", "inventory")
+	os.Setenv("OIDC_CLIENT_SECRET", "client-secret")
+	os.Setenv("OIDC_REDIRECT_URL", "http://localhost:8080/api/v1/auth/oidc/callback")
+	os.Setenv("OIDC_ROLE_MAPPING", "inventory-admins=admin,inventory-managers=manager,warehouse=clerk")
+}
+
+func (suite *OIDCTestSuite) TearDownSuite() {
+	for _, key := range []string{"OIDC_ISSUER_URL", "OIDC_CLIENT_ID", "OIDC_CLIENT_SECRET", "OIDC_REDIRECT_URL", "OIDC_ROLE_MAPPING"} {
+		os.Unsetenv(key)
+	}
+	suite.idp.server.Close()
+}
+
+func (suite *OIDCTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+	suite.db.Where("external_id IS NOT NULL").Delete(&models.User{})
+}
+
+// startLogin follows /auth/oidc/login and returns the IdP URL and the
+// cookie holding the login state.
+func (suite *OIDCTestSuite) startLogin() (*url.URL, *http.Cookie) {
+	w := performRequest(suite.router, "GET", "/api/v1/auth/oidc/login", nil, "")
+	require.Equal(suite.T(), http.StatusFound, w.Code)
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(suite.T(), err)
+	cookies := w.Result().Cookies()
+	require.Len(suite.T(), cookies, 1)
+	return location, cookies[0]
+}
+
+func (suite *OIDCTestSuite) callback(query url.Values, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/api/v1/auth/oidc/callback?"+query.Encode(), nil)
+	req.AddCookie(cookie)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *OIDCTestSuite) TestAuthorizationCodeFlowWithPKCE() {
+	location, cookie := suite.startLogin()
+	assert.Equal(suite.T(), suite.idp.server.URL+"/authorize", location.Scheme+"://"+location.Host+location.Path)
+	assert.Equal(suite.T(), "S256", location.Query().Get("code_challenge_method"))
+	assert.Equal(suite.T(), "inventory", location.Query().Get("client_id"))
+	assert.True(suite.T(), cookie.HttpOnly)
+
+	code := suite.idp.authorize(location, jwt.MapClaims{
+		"preferred_username": "jdoe",
+		"groups":             []string{"staff", "inventory-managers"},
+	})
+	w := suite.callback(url.Values{"code": {code}, "state": {location.Query().Get("state")}}, cookie)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var pair tokenPair
+	decodeJSON(suite.T(), w, &pair)
+	assert.NotEmpty(suite.T(), pair.RefreshToken)
+
+	var user models.User
+	require.NoError(suite.T(), suite.db.First(&user, "username = ?", "jdoe").Error)
+	assert.Equal(suite.T(), models.RoleManager, user.Role)
+	require.NotNil(suite.T(), user.ExternalID)
+	assert.Equal(suite.T(), suite.idp.server.URL+"|user-123", *user.ExternalID)
+	assert.Empty(suite.T(), user.PasswordHash)
+
+	w = performRequest(suite.router, "GET", "/api/v1/audit", nil, pair.Token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *OIDCTestSuite) TestCallbackRejectsBadStateAndVerifier() {
+	location, cookie := suite.startLogin()
+	code := suite.idp.authorize(location, jwt.MapClaims{"groups": []string{"warehouse"}})
+
+	w := suite.callback(url.Values{"code": {code}, "state": {"forged"}}, cookie)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// A code intercepted by someone without the verifier is useless: the
+	// other login's cookie carries a different verifier.
+	otherLocation, otherCookie := suite.startLogin()
+	w = suite.callback(url.Values{"code": {code}, "state": {otherLocation.Query().Get("state")}}, otherCookie)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *OIDCTestSuite) TestUnmappedGroupsAreDenied() {
+	location, cookie := suite.startLogin()
+	code := suite.idp.authorize(location, jwt.MapClaims{"preferred_username": "guest", "groups": []string{"marketing"}})
+
+	w := suite.callback(url.Values{"code": {code}, "state": {location.Query().Get("state")}}, cookie)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *OIDCTestSuite) TestIdPAccessTokensAreAccepted() {
+	token := suite.idp.sign(suite.T(), jwt.MapClaims{"sub": "svc-1", "preferred_username": "erp-sync", "groups": "inventory-admins"})
+	w := performRequest(suite.router, "GET", "/api/v1/users", nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	clerk := suite.idp.sign(suite.T(), jwt.MapClaims{"sub": "svc-2", "groups": []string{"warehouse"}})
+	w = performRequest(suite.router, "GET", "/api/v1/users", nil, clerk)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	expired := suite.idp.sign(suite.T(), jwt.MapClaims{"groups": "inventory-admins", "exp": time.Now().Add(-time.Minute).Unix()})
+	w = performRequest(suite.router, "GET", "/api/v1/users", nil, expired)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	otherAudience := suite.idp.sign(suite.T(), jwt.MapClaims{"groups": "inventory-admins", "aud": "someone-else"})
+	w = performRequest(suite.router, "GET", "/api/v1/users", nil, otherAudience)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *OIDCTestSuite) TestIdPAccessTokensCannotLogOut() {
+	token := suite.idp.sign(suite.T(), jwt.MapClaims{"sub": "svc-1", "preferred_username": "erp-sync", "groups": "inventory-admins"})
+	w := performRequest(suite.router, "POST", "/api/v1/logout", nil, token)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/users", nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func TestOIDCTestSuite(t *testing.T) {
+	suite.Run(t, new(OIDCTestSuite))
+}
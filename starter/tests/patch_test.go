@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/models"
+)
+
+type PatchTestSuite struct {
+	apiSuite
+}
+
+func (suite *PatchTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+	suite.db.Create(&models.Item{ID: "1", Name: "Toaster", Stock: 12, Price: 45.00})
+}
+
+func (suite *PatchTestSuite) patch(contentType, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("PATCH", "/api/v1/inventory/1", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+suite.jwtToken)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *PatchTestSuite) TestMergePatchUpdatesOnlyGivenFields() {
+	w := suite.patch("application/merge-patch+json", `{"price": 39.99}`)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var item models.Item
+	suite.db.First(&item, "id = ?", "1")
+	assert.Equal(suite.T(), 39.99, item.Price)
+	assert.Equal(suite.T(), "Toaster", item.Name)
+	assert.Equal(suite.T(), 12, item.Stock)
+	assert.Equal(suite.T(), 2, item.Version)
+}
+
+func (suite *PatchTestSuite) TestJSONPatchOperations() {
+	w := suite.patch("application/json-patch+json",
+		`[{"op": "test", "path": "/stock", "value": 12}, {"op": "replace", "path": "/stock", "value": 20}]`)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.patch("application/json-patch+json",
+		`[{"op": "test", "path": "/stock", "value": 12}, {"op": "replace", "path": "/stock", "value": 30}]`)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+
+	var item models.Item
+	suite.db.First(&item, "id = ?", "1")
+	assert.Equal(suite.T(), 20, item.Stock)
+}
+
+func (suite *PatchTestSuite) TestSoldOutItemsCanBePatched() {
+	suite.db.Model(&models.Item{}).Where("id = ?", "1").Update("stock", 0)
+
+	w := suite.patch("application/merge-patch+json", `{"price": 49.99}`)
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var item models.Item
+	suite.db.First(&item, "id = ?", "1")
+	assert.Equal(suite.T(), 49.99, item.Price)
+	assert.Equal(suite.T(), 0, item.Stock)
+}
+
+func (suite *PatchTestSuite) TestPatchedItemIsValidated() {
+	w := suite.patch("application/merge-patch+json", `{"price": -1}`)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.patch("application/merge-patch+json", `{"id": "other"}`)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.patch("application/json", `{"price": 10}`)
+	assert.Equal(suite.T(), http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestPatchTestSuite(t *testing.T) {
+	suite.Run(t, new(PatchTestSuite))
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/middleware"
+	"inventory_management/models"
+)
+
+type RBACTestSuite struct {
+	apiSuite
+	tokens map[models.Role]string
+}
+
+func (suite *RBACTestSuite) SetupSuite() {
+	suite.apiSuite.SetupSuite()
+
+	suite.tokens = make(map[models.Role]string)
+	for _, role := range models.Roles {
+		username := string(role) + "-user"
+		user := models.User{ID: username, Username: username, Role: role, PasswordHash: "unused"}
+		require.NoError(suite.T(), suite.db.Create(&user).Error)
+
+		token, err := middleware.GenerateJWT(username, role)
+		require.NoError(suite.T(), err)
+		suite.tokens[role] = token
+	}
+}
+
+func (suite *RBACTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+
+	suite.db.Create(&models.Item{ID: "1", Name: "Stapler", Stock: 10, Price: 12.50, Status: models.ItemStatusActive})
+}
+
+func (suite *RBACTestSuite) TestViewerCanOnlyRead() {
+	token := suite.tokens[models.RoleViewer]
+
+	w := performRequest(suite.router, "GET", "/api/v1/inventory/1", nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/inventory",
+		gin.H{"name": "Pen", "stock": 1, "price": 1.0}, token)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/inventory/1/stock",
+		gin.H{"delta": 5, "reason": "receipt"}, token)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/audit", nil, token)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *RBACTestSuite) TestClerkCanAdjustStockButNotPrice() {
+	token := suite.tokens[models.RoleClerk]
+
+	w := performRequest(suite.router, "POST", "/api/v1/inventory/1/stock",
+		gin.H{"delta": 5, "reason": "receipt"}, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performRequest(suite.router, "PUT", "/api/v1/inventory/1",
+		gin.H{"name": "Stapler", "stock": 20, "price": 12.50}, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performRequest(suite.router, "PUT", "/api/v1/inventory/1",
+		gin.H{"name": "Stapler", "stock": 20, "price": 99.99}, token)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "inventory:price")
+
+	w = performRequest(suite.router, "DELETE", "/api/v1/inventory/1", nil, token)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	var item models.Item
+	require.NoError(suite.T(), suite.db.First(&item, "id = ?", "1").Error)
+	assert.Equal(suite.T(), 20, item.Stock)
+	assert.Equal(suite.T(), 12.50, item.Price)
+}
+
+func (suite *RBACTestSuite) TestManagerCanChangePriceAndDelete() {
+	token := suite.tokens[models.RoleManager]
+
+	w := performRequest(suite.router, "PUT", "/api/v1/inventory/1",
+		gin.H{"name": "Stapler", "stock": 10, "price": 14.00}, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performRequest(suite.router, "DELETE", "/api/v1/inventory/1", nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/users", nil, token)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *RBACTestSuite) TestRoleChangeInvalidatesTokens() {
+	user := models.User{ID: "promoted", Username: "promoted", Role: models.RoleClerk, PasswordHash: "unused"}
+	require.NoError(suite.T(), suite.db.Create(&user).Error)
+	defer suite.db.Delete(&user)
+	token, err := middleware.GenerateJWT("promoted", models.RoleClerk)
+	require.NoError(suite.T(), err)
+
+	w := performRequest(suite.router, "PUT", "/api/v1/users/promoted/role",
+		gin.H{"role": "superuser"}, suite.tokens[models.RoleAdmin])
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = performRequest(suite.router, "PUT", "/api/v1/users/promoted/role",
+		gin.H{"role": "manager"}, suite.tokens[models.RoleAdmin])
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/inventory/1/stock",
+		gin.H{"delta": 1, "reason": "receipt"}, token)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	w = performRequest(suite.router, "PUT", "/api/v1/users/admin-user/role",
+		gin.H{"role": "viewer"}, suite.tokens[models.RoleAdmin])
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func TestRBACTestSuite(t *testing.T) {
+	suite.Run(t, new(RBACTestSuite))
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/middleware"
+	"inventory_management/models"
+)
+
+type ScopeTestSuite struct {
+	apiSuite
+}
+
+type scopedLogin struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+func (suite *ScopeTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+	suite.db.Where("1 = 1").Delete(&models.RefreshToken{})
+
+	suite.db.Create(&models.Item{ID: "1", Name: "Crate", Stock: 4, Price: 9.00, Status: models.ItemStatusActive})
+}
+
+func (suite *ScopeTestSuite) login(scope string) scopedLogin {
+	w := performRequest(suite.router, "POST", "/api/v1/login",
+		gin.H{"username": "admin", "password": "password", "scope": scope}, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var login scopedLogin
+	decodeJSON(suite.T(), w, &login)
+	return login
+}
+
+func (suite *ScopeTestSuite) TestReadOnlyTokenCannotWrite() {
+	login := suite.login("inventory:read")
+	assert.Equal(suite.T(), "inventory:read", login.Scope)
+
+	w := performRequest(suite.router, "GET", "/api/v1/inventory/1", nil, login.Token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/inventory/1/stock",
+		gin.H{"delta": 1, "reason": "receipt"}, login.Token)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = performRequest(suite.router, "DELETE", "/api/v1/inventory/1", nil, login.Token)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/users", nil, login.Token)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *ScopeTestSuite) TestDefaultScopesFollowTheRole() {
+	assert.Equal(suite.T(), "inventory:read inventory:write inventory:delete admin", suite.login("").Scope)
+
+	w := performRequest(suite.router, "POST", "/api/v1/login",
+		gin.H{"username": "admin", "password": "password", "scope": "inventory:everything"}, "")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// Without a scope parameter a viewer only gets what the role allows.
+	assert.Equal(suite.T(), []string{"inventory:read"}, middleware.GrantedScopes(models.RoleViewer, nil))
+}
+
+func (suite *ScopeTestSuite) TestScopesBeyondTheRoleAreRejected() {
+	viewer := models.User{ID: "scoped-viewer-id", Username: "scoped-viewer", Role: models.RoleViewer}
+	require.NoError(suite.T(), viewer.SetPassword("a-long-password"))
+	require.NoError(suite.T(), suite.db.Create(&viewer).Error)
+
+	w := performRequest(suite.router, "POST", "/api/v1/login",
+		gin.H{"username": "scoped-viewer", "password": "a-long-password", "scope": "inventory:read admin"}, "")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "invalid_scope")
+
+	w = performRequest(suite.router, "POST", "/api/v1/login",
+		gin.H{"username": "scoped-viewer", "password": "a-long-password", "scope": "inventory:read"}, "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *ScopeTestSuite) TestReducedScopeSurvivesRefresh() {
+	login := suite.login("inventory:read inventory:write")
+
+	w := performRequest(suite.router, "POST", "/api/v1/token/refresh", gin.H{"refresh_token": login.RefreshToken}, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var refreshed scopedLogin
+	decodeJSON(suite.T(), w, &refreshed)
+	assert.Equal(suite.T(), "inventory:read inventory:write", refreshed.Scope)
+
+	w = performRequest(suite.router, "POST", "/api/v1/inventory/1/stock",
+		gin.H{"delta": 1, "reason": "receipt"}, refreshed.Token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performRequest(suite.router, "DELETE", "/api/v1/inventory/1", nil, refreshed.Token)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *ScopeTestSuite) TestReadsCanRequireAuthentication() {
+	os.Setenv("REQUIRE_AUTH_FOR_READS", "true")
+	defer os.Unsetenv("REQUIRE_AUTH_FOR_READS")
+
+	w := performRequest(suite.router, "GET", "/api/v1/inventory", nil, "")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	admin := suite.login("admin")
+	w = performRequest(suite.router, "GET", "/api/v1/inventory/search?q=crate", nil, admin.Token)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	reader := suite.login("inventory:read")
+	w = performRequest(suite.router, "GET", "/api/v1/tags", nil, reader.Token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func TestScopeTestSuite(t *testing.T) {
+	suite.Run(t, new(ScopeTestSuite))
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/handlers"
+	"inventory_management/models"
+)
+
+type SearchTestSuite struct {
+	apiSuite
+}
+
+func (suite *SearchTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+
+	items := []models.Item{
+		{ID: "1", Name: "Wireless Headphones", Stock: 10, Price: 199.99},
+		{ID: "2", Name: "Headphone Stand", Stock: 5, Price: 29.99},
+		{ID: "3", Name: "Mouse", Stock: 30, Price: 49.99},
+		{ID: "4", Name: "Monitor <4K>", Stock: 12, Price: 299.99},
+		{ID: "5", Name: "Studio Headphones", Stock: 3, Price: 149.99, Status: models.ItemStatusArchived},
+	}
+	suite.db.Create(&items)
+}
+
+func (suite *SearchTestSuite) search(query string) handlers.SearchResponse {
+	w := performRequest(suite.router, "GET", "/api/v1/inventory/search?"+query, nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response handlers.SearchResponse
+	decodeJSON(suite.T(), w, &response)
+	return response
+}
+
+func (suite *SearchTestSuite) TestSearchToleratesTyposAndRanksExactMatchesFirst() {
+	response := suite.search("q=hedphones")
+	require.Len(suite.T(), response.Data, 2)
+	assert.Equal(suite.T(), "1", response.Data[0].ID)
+	assert.Equal(suite.T(), "Wireless <mark>Headphones</mark>", response.Data[0].Highlight)
+
+	response = suite.search("q=headphone")
+	require.Len(suite.T(), response.Data, 2)
+	assert.Equal(suite.T(), "2", response.Data[0].ID)
+	assert.Greater(suite.T(), response.Data[0].Score, response.Data[1].Score)
+}
+
+func (suite *SearchTestSuite) TestPrefixMatchingForTypeAhead() {
+	response := suite.search("q=mo")
+	assert.Equal(suite.T(), []string{"3", "4"}, searchResultIDs(response))
+	assert.Equal(suite.T(), "<mark>Monitor</mark> &lt;4K&gt;", response.Data[1].Highlight)
+
+	response = suite.search("q=wire+head")
+	assert.Equal(suite.T(), []string{"1"}, searchResultIDs(response))
+}
+
+func (suite *SearchTestSuite) TestSearchHonoursListFilters() {
+	response := suite.search("q=headphones&status=all")
+	assert.Len(suite.T(), response.Data, 3)
+
+	w := performRequest(suite.router, "GET", "/api/v1/inventory/search", nil, "")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func searchResultIDs(response handlers.SearchResponse) []string {
+	result := make([]string, len(response.Data))
+	for i, r := range response.Data {
+		result[i] = r.ID
+	}
+	return result
+}
+
+func TestSearchTestSuite(t *testing.T) {
+	suite.Run(t, new(SearchTestSuite))
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/database"
+	"inventory_management/middleware"
+	"inventory_management/models"
+)
+
+type SoftDeleteTestSuite struct {
+	apiSuite
+}
+
+func (suite *SoftDeleteTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+	suite.db.Create(&models.Item{ID: "1", Name: "Lamp", Stock: 3, Price: 25.00})
+}
+
+func (suite *SoftDeleteTestSuite) TestDeleteHidesAndRestoreReturnsItem() {
+	w := performRequest(suite.router, "DELETE", "/api/v1/inventory/1", nil, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory/1", nil, "")
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/inventory/1/restore", nil, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory/1", nil, "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *SoftDeleteTestSuite) TestIncludeDeletedRequiresAuthentication() {
+	suite.db.Delete(&models.Item{}, "id = ?", "1")
+
+	w := performRequest(suite.router, "GET", "/api/v1/inventory?include_deleted=true", nil, "")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory?include_deleted=true", nil, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response struct {
+		Total int64         `json:"total"`
+		Data  []models.Item `json:"data"`
+	}
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), int64(1), response.Total)
+	assert.True(suite.T(), response.Data[0].DeletedAt.Valid)
+}
+
+func (suite *SoftDeleteTestSuite) TestIncludeDeletedRequiresDeletePermission() {
+	suite.db.Create(&models.User{ID: "lamp-viewer-id", Username: "lamp-viewer", Role: models.RoleViewer, PasswordHash: "unused"})
+	viewer, err := middleware.GenerateJWT("lamp-viewer", models.RoleViewer)
+	require.NoError(suite.T(), err)
+	key, _, err := database.CreateAPIKey(suite.db, models.APIKey{Name: "reader", Scopes: []string{"inventory:read"}})
+	require.NoError(suite.T(), err)
+
+	w := performRequest(suite.router, "GET", "/api/v1/inventory?include_deleted=true", nil, viewer)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory/1?include_deleted=true", nil, viewer)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = performKeyRequest(suite.router, "GET", "/api/v1/inventory/export?include_deleted=true", "", key)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *SoftDeleteTestSuite) TestRestoreRequiresDeletedItem() {
+	w := performRequest(suite.router, "POST", "/api/v1/inventory/1/restore", nil, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *SoftDeleteTestSuite) TestPurgeRemovesExpiredItems() {
+	suite.db.Create(&models.Item{ID: "2", Name: "Chair", Stock: 1, Price: 80.00})
+	suite.db.Delete(&models.Item{}, "id = ?", "1")
+	suite.db.Delete(&models.Item{}, "id = ?", "2")
+	suite.db.Unscoped().Model(&models.Item{}).Where("id = ?", "1").
+		Update("deleted_at", time.Now().Add(-48*time.Hour))
+
+	purged, err := database.PurgeDeletedItems(time.Now().Add(-24 * time.Hour))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), purged)
+
+	var remaining int64
+	suite.db.Unscoped().Model(&models.Item{}).Count(&remaining)
+	assert.Equal(suite.T(), int64(1), remaining)
+}
+
+func TestSoftDeleteTestSuite(t *testing.T) {
+	suite.Run(t, new(SoftDeleteTestSuite))
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/handlers"
+	"inventory_management/models"
+)
+
+type SortFieldsTestSuite struct {
+	apiSuite
+}
+
+func (suite *SortFieldsTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+
+	items := []models.Item{
+		{ID: "1", Name: "Pen", Stock: 10, Price: 1.50},
+		{ID: "2", Name: "Eraser", Stock: 10, Price: 0.50},
+		{ID: "3", Name: "Ruler", Stock: 40, Price: 2.00},
+		{ID: "4", Name: "Pencil", Stock: 10, Price: 0.75},
+		{ID: "5", Name: "Stapler", Stock: 5, Price: 12.00},
+	}
+	suite.db.Create(&items)
+	suite.db.Model(&items[0]).Association("Tags").Append(&models.Tag{ID: "t1", Name: "office"})
+}
+
+func (suite *SortFieldsTestSuite) TestMultiColumnSort() {
+	w := performRequest(suite.router, "GET", "/api/v1/inventory?sort=-stock,name", nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var response handlers.PaginationResponse
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), []string{"3", "2", "1", "4", "5"}, itemIDs(response.Data))
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory?sort=stock,-price", nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), []string{"5", "1", "4", "2", "3"}, itemIDs(response.Data))
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory?sort=-weight", nil, "")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *SortFieldsTestSuite) TestCursorPaginationWithMultipleKeys() {
+	var response handlers.CursorPaginationResponse
+	var seen []string
+	path := "/api/v1/inventory?sort=-stock,name&limit=2"
+	for i := 0; i < 3; i++ {
+		w := performRequest(suite.router, "GET", path, nil, "")
+		require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+		decodeJSON(suite.T(), w, &response)
+		seen = append(seen, itemIDs(response.Data)...)
+		path = "/api/v1/inventory?sort=-stock,name&limit=2&cursor=" + response.NextCursor
+	}
+	assert.Equal(suite.T(), []string{"3", "2", "1", "4", "5"}, seen)
+	assert.False(suite.T(), response.HasNext)
+
+	w := performRequest(suite.router, "GET", "/api/v1/inventory?sort=-stock,name&limit=2&cursor="+response.PrevCursor, nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), []string{"1", "4"}, itemIDs(response.Data))
+}
+
+func (suite *SortFieldsTestSuite) TestSparseFieldsets() {
+	w := performRequest(suite.router, "GET", "/api/v1/inventory?fields=name,price&sort=name&page_size=2", nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var body struct {
+		Data  []map[string]json.RawMessage `json:"data"`
+		Total int64                        `json:"total"`
+	}
+	decodeJSON(suite.T(), w, &body)
+	assert.Equal(suite.T(), int64(5), body.Total)
+	require.Len(suite.T(), body.Data, 2)
+	assert.Len(suite.T(), body.Data[0], 2)
+	assert.JSONEq(suite.T(), `"Eraser"`, string(body.Data[0]["name"]))
+	assert.JSONEq(suite.T(), `0.5`, string(body.Data[0]["price"]))
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory?fields=id,tags&limit=1&sort=-price", nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	body.Data = nil
+	decodeJSON(suite.T(), w, &body)
+	require.Len(suite.T(), body.Data, 1)
+	assert.Len(suite.T(), body.Data[0], 1)
+	assert.JSONEq(suite.T(), `"5"`, string(body.Data[0]["id"]))
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory?fields=id,tags&sort=name&name=pen", nil, "")
+	body.Data = nil
+	decodeJSON(suite.T(), w, &body)
+	require.Len(suite.T(), body.Data, 2)
+	assert.Contains(suite.T(), string(body.Data[0]["tags"]), "office")
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory?fields=name,password", nil, "")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func TestSortFieldsTestSuite(t *testing.T) {
+	suite.Run(t, new(SortFieldsTestSuite))
+}
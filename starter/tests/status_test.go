@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/handlers"
+	"inventory_management/models"
+)
+
+type StatusTestSuite struct {
+	apiSuite
+}
+
+func (suite *StatusTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+
+	items := []models.Item{
+		{ID: "1", Name: "Desk", Stock: 10, Price: 150.00, Status: models.ItemStatusActive},
+		{ID: "2", Name: "Fax Machine", Stock: 4, Price: 90.00, Status: models.ItemStatusDiscontinued},
+		{ID: "3", Name: "Pager", Stock: 0, Price: 10.00, Status: models.ItemStatusArchived},
+	}
+	suite.db.Create(&items)
+}
+
+func (suite *StatusTestSuite) TestListShowsActiveItemsByDefault() {
+	var response handlers.PaginationResponse
+	w := performRequest(suite.router, "GET", "/api/v1/inventory", nil, "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), int64(1), response.Total)
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory?status=all", nil, "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), int64(3), response.Total)
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory?status=unknown", nil, "")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *StatusTestSuite) TestInvalidTransitionIsRejected() {
+	w := performRequest(suite.router, "PUT", "/api/v1/inventory/3", gin.H{
+		"name": "Pager", "stock": 1, "price": 10.00, "status": "active",
+	}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	w = performRequest(suite.router, "PUT", "/api/v1/inventory/1", gin.H{
+		"name": "Desk", "stock": 10, "price": 150.00, "status": "discontinued",
+	}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *StatusTestSuite) TestDiscontinuedItemsOnlyAcceptReceipts() {
+	w := performRequest(suite.router, "POST", "/api/v1/inventory/2/stock",
+		gin.H{"delta": -1, "reason": "order"}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	w = performRequest(suite.router, "PUT", "/api/v1/inventory/2", gin.H{
+		"name": "Fax Machine", "stock": 2, "price": 90.00,
+	}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/inventory/2/stock",
+		gin.H{"delta": 6, "reason": "receipt"}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.Item `json:"data"`
+	}
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), 10, response.Data.Stock)
+}
+
+func (suite *StatusTestSuite) TestOrderCannotOversell() {
+	w := performRequest(suite.router, "POST", "/api/v1/inventory/1/stock",
+		gin.H{"delta": -11, "reason": "order"}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func TestStatusTestSuite(t *testing.T) {
+	suite.Run(t, new(StatusTestSuite))
+}
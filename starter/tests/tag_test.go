@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/handlers"
+	"inventory_management/models"
+)
+
+type TagTestSuite struct {
+	apiSuite
+}
+
+func (suite *TagTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+	suite.db.Exec("DELETE FROM item_tags")
+	suite.db.Where("1 = 1").Delete(&models.Tag{})
+
+	items := []models.Item{
+		{ID: "1", Name: "Glass Vase", Stock: 5, Price: 40.00},
+		{ID: "2", Name: "Summer Hat", Stock: 15, Price: 20.00},
+		{ID: "3", Name: "Beach Towel", Stock: 25, Price: 15.00},
+	}
+	suite.db.Create(&items)
+}
+
+func (suite *TagTestSuite) TestAddAndRemoveItemTags() {
+	w := performRequest(suite.router, "POST", "/api/v1/inventory/1/tags",
+		gin.H{"tags": []string{"Fragile", " clearance ", "fragile"}}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.Item `json:"data"`
+	}
+	decodeJSON(suite.T(), w, &response)
+	assert.Len(suite.T(), response.Data.Tags, 2)
+
+	w = performRequest(suite.router, "DELETE", "/api/v1/inventory/1/tags",
+		gin.H{"tags": []string{"clearance"}}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	decodeJSON(suite.T(), w, &response)
+	assert.Len(suite.T(), response.Data.Tags, 1)
+	assert.Equal(suite.T(), "fragile", response.Data.Tags[0].Name)
+}
+
+func (suite *TagTestSuite) TestAddTagsToMissingItem() {
+	w := performRequest(suite.router, "POST", "/api/v1/inventory/missing/tags",
+		gin.H{"tags": []string{"fragile"}}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TagTestSuite) TestFilterByTagsAnyAndAll() {
+	w := performRequest(suite.router, "POST", "/api/v1/inventory/tags", gin.H{
+		"item_ids": []string{"2", "3"},
+		"add":      []string{"seasonal"},
+	}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/inventory/3/tags",
+		gin.H{"tags": []string{"clearance"}}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response handlers.PaginationResponse
+	w = performRequest(suite.router, "GET", "/api/v1/inventory?tags=seasonal,clearance", nil, "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), int64(2), response.Total)
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory?tags=seasonal,clearance&tags_match=all", nil, "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	decodeJSON(suite.T(), w, &response)
+	assert.Equal(suite.T(), int64(1), response.Total)
+	assert.Equal(suite.T(), "3", response.Data[0].ID)
+}
+
+func TestTagTestSuite(t *testing.T) {
+	suite.Run(t, new(TagTestSuite))
+}
@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/middleware"
+	"inventory_management/models"
+)
+
+type TenantTestSuite struct {
+	apiSuite
+	tenantToken string
+}
+
+func (suite *TenantTestSuite) SetupSuite() {
+	suite.apiSuite.SetupSuite()
+
+	user := models.User{ID: "acme-admin", TenantID: "acme", Username: "acme-admin", Role: models.RoleAdmin, PasswordHash: "unused"}
+	require.NoError(suite.T(), suite.db.Create(&user).Error)
+
+	token, err := middleware.GenerateJWT("acme-admin", models.RoleAdmin)
+	require.NoError(suite.T(), err)
+	suite.tenantToken = token
+}
+
+func (suite *TenantTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+	suite.db.Where("username NOT IN ?", []string{"admin", "acme-admin"}).Delete(&models.User{})
+
+	suite.db.Create(&models.Item{ID: "1", Name: "Pallet", Stock: 8, Price: 30.00, Status: models.ItemStatusActive})
+}
+
+func (suite *TenantTestSuite) request(method, path string, body interface{}, token, tenant string) *httptest.ResponseRecorder {
+	var reader bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&reader).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if tenant != "" {
+		req.Header.Set("X-Tenant-ID", tenant)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *TenantTestSuite) TestCrossTenantReadsReturnNotFound() {
+	w := performRequest(suite.router, "GET", "/api/v1/inventory/1", nil, suite.tenantToken)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = suite.request("GET", "/api/v1/inventory/1", nil, suite.tenantToken, "acme")
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = performRequest(suite.router, "PUT", "/api/v1/inventory/1",
+		gin.H{"name": "Stolen", "stock": 0, "price": 1.00}, suite.tenantToken)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = performRequest(suite.router, "DELETE", "/api/v1/inventory/1", nil, suite.tenantToken)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory/1", nil, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Pallet")
+}
+
+func (suite *TenantTestSuite) TestWritesLandInTheTokensTenant() {
+	w := performRequest(suite.router, "POST", "/api/v1/inventory",
+		gin.H{"name": "Crate", "sku": "CR-1", "stock": 3, "price": 12.00}, suite.tenantToken)
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+
+	// SKUs are only unique within a tenant.
+	w = performRequest(suite.router, "POST", "/api/v1/inventory",
+		gin.H{"name": "Other crate", "sku": "CR-1", "stock": 1, "price": 10.00}, suite.jwtToken)
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+
+	var crate models.Item
+	require.NoError(suite.T(), suite.db.First(&crate, "name = ?", "Crate").Error)
+	assert.Equal(suite.T(), "acme", crate.TenantID)
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory", nil, suite.tenantToken)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Crate")
+	assert.NotContains(suite.T(), w.Body.String(), "Pallet")
+
+	w = performRequest(suite.router, "GET", "/api/v1/inventory/search?q=crate", nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Other crate")
+	assert.NotContains(suite.T(), w.Body.String(), `"Crate"`)
+}
+
+func (suite *TenantTestSuite) TestAnonymousRequestsCannotPickATenant() {
+	suite.db.Create(&models.Item{ID: "2", TenantID: "acme", Name: "Crate", Stock: 3, Price: 12.00, Status: models.ItemStatusActive})
+
+	for _, path := range []string{"/api/v1/inventory", "/api/v1/inventory/2", "/api/v1/inventory/search?q=crate", "/api/v1/inventory/export"} {
+		w := suite.request("GET", path, nil, "", "acme")
+		assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, path)
+	}
+
+	w := suite.request("GET", "/api/v1/inventory/2", nil, "", "")
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TenantTestSuite) TestTenantHeaderMustMatchCredentials() {
+	w := suite.request("GET", "/api/v1/users", nil, suite.tenantToken, "default")
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = suite.request("GET", "/api/v1/inventory", nil, "", "Not A Tenant")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.request("GET", "/api/v1/users", nil, suite.tenantToken, "acme")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "acme-admin")
+	assert.NotContains(suite.T(), w.Body.String(), `"username":"admin"`)
+}
+
+func (suite *TenantTestSuite) TestOnlyDefaultTenantCreatesUsersElsewhere() {
+	w := performRequest(suite.router, "POST", "/api/v1/users",
+		gin.H{"username": "globex-admin", "password": "a-long-password", "role": "admin", "tenant_id": "globex"}, suite.jwtToken)
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+
+	var created struct {
+		Data models.User `json:"data"`
+	}
+	decodeJSON(suite.T(), w, &created)
+	assert.Equal(suite.T(), "globex", created.Data.TenantID)
+
+	w = performRequest(suite.router, "POST", "/api/v1/users",
+		gin.H{"username": "intruder", "password": "a-long-password", "tenant_id": "globex"}, suite.tenantToken)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = performRequest(suite.router, "PUT", "/api/v1/users/"+created.Data.ID+"/role",
+		gin.H{"role": "viewer"}, suite.tenantToken)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TenantTestSuite) TestAPIKeysActForTheirTenant() {
+	w := performRequest(suite.router, "POST", "/api/v1/api-keys",
+		gin.H{"name": "acme sync", "scopes": []string{"inventory:read", "inventory:stock"}}, suite.tenantToken)
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Key string `json:"key"`
+	}
+	decodeJSON(suite.T(), w, &created)
+
+	w = performKeyRequest(suite.router, "POST", "/api/v1/inventory/1/stock", `{"delta": 5, "reason": "receipt"}`, created.Key)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	var item models.Item
+	require.NoError(suite.T(), suite.db.First(&item, "id = ?", "1").Error)
+	assert.Equal(suite.T(), 8, item.Stock)
+}
+
+func TestTenantTestSuite(t *testing.T) {
+	suite.Run(t, new(TenantTestSuite))
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/models"
+)
+
+type TokenTestSuite struct {
+	apiSuite
+}
+
+type tokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+func (suite *TokenTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+	suite.db.Where("1 = 1").Delete(&models.RefreshToken{})
+}
+
+func (suite *TokenTestSuite) login() tokenPair {
+	w := performRequest(suite.router, "POST", "/api/v1/login", gin.H{"username": "admin", "password": "password"}, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var pair tokenPair
+	decodeJSON(suite.T(), w, &pair)
+	return pair
+}
+
+func (suite *TokenTestSuite) refresh(refreshToken string) (int, tokenPair) {
+	w := performRequest(suite.router, "POST", "/api/v1/token/refresh", gin.H{"refresh_token": refreshToken}, "")
+	var pair tokenPair
+	decodeJSON(suite.T(), w, &pair)
+	return w.Code, pair
+}
+
+func (suite *TokenTestSuite) TestLoginIssuesShortLivedTokenAndHashedRefreshToken() {
+	pair := suite.login()
+	assert.NotEmpty(suite.T(), pair.Token)
+	assert.NotEmpty(suite.T(), pair.RefreshToken)
+	assert.Equal(suite.T(), 900, pair.ExpiresIn)
+
+	var stored models.RefreshToken
+	require.NoError(suite.T(), suite.db.First(&stored).Error)
+	assert.NotEqual(suite.T(), pair.RefreshToken, stored.TokenHash)
+	assert.NotContains(suite.T(), stored.TokenHash, pair.RefreshToken)
+}
+
+func (suite *TokenTestSuite) TestRefreshRotatesAndDetectsReuse() {
+	first := suite.login()
+
+	code, second := suite.refresh(first.RefreshToken)
+	require.Equal(suite.T(), http.StatusOK, code)
+	assert.NotEmpty(suite.T(), second.Token)
+	assert.NotEqual(suite.T(), first.RefreshToken, second.RefreshToken)
+
+	code, _ = suite.refresh(first.RefreshToken)
+	assert.Equal(suite.T(), http.StatusUnauthorized, code)
+
+	// The reuse revoked the whole family, including the rotated token.
+	code, _ = suite.refresh(second.RefreshToken)
+	assert.Equal(suite.T(), http.StatusUnauthorized, code)
+
+	code, _ = suite.refresh("not-a-token")
+	assert.Equal(suite.T(), http.StatusUnauthorized, code)
+}
+
+func (suite *TokenTestSuite) TestLogoutRevokesAccessAndRefreshTokens() {
+	pair := suite.login()
+
+	w := performRequest(suite.router, "POST", "/api/v1/logout", gin.H{"refresh_token": pair.RefreshToken}, pair.Token)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/users", nil, pair.Token)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "revoked")
+
+	code, _ := suite.refresh(pair.RefreshToken)
+	assert.Equal(suite.T(), http.StatusUnauthorized, code)
+}
+
+func (suite *TokenTestSuite) TestLogoutWithAnUnknownRefreshTokenRevokesNothing() {
+	pair := suite.login()
+
+	w := performRequest(suite.router, "POST", "/api/v1/logout", gin.H{"refresh_token": "not-a-token"}, pair.Token)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/users", nil, pair.Token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *TokenTestSuite) TestDisablingUserRevokesRefreshTokens() {
+	user := models.User{ID: "leaver", Username: "leaver", Role: models.RoleViewer}
+	require.NoError(suite.T(), user.SetPassword("leaver-password"))
+	require.NoError(suite.T(), suite.db.Create(&user).Error)
+	defer suite.db.Delete(&user)
+
+	w := performRequest(suite.router, "POST", "/api/v1/login", gin.H{"username": "leaver", "password": "leaver-password"}, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var pair tokenPair
+	decodeJSON(suite.T(), w, &pair)
+
+	admin := suite.login()
+	w = performRequest(suite.router, "POST", "/api/v1/users/leaver/disable", nil, admin.Token)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	code, _ := suite.refresh(pair.RefreshToken)
+	assert.Equal(suite.T(), http.StatusUnauthorized, code)
+}
+
+func TestTokenTestSuite(t *testing.T) {
+	suite.Run(t, new(TokenTestSuite))
+}
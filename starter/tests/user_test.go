@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"inventory_management/middleware"
+	"inventory_management/models"
+)
+
+type UserTestSuite struct {
+	apiSuite
+}
+
+func (suite *UserTestSuite) SetupTest() {
+	suite.apiSuite.SetupTest()
+	suite.db.Where("username <> ?", "admin").Delete(&models.User{})
+}
+
+func (suite *UserTestSuite) createUser(username, password string, role models.Role) models.User {
+	user := models.User{ID: username + "-id", Username: username, Role: role}
+	require.NoError(suite.T(), user.SetPassword(password))
+	require.NoError(suite.T(), suite.db.Create(&user).Error)
+	return user
+}
+
+func (suite *UserTestSuite) login(username, password string) (int, string) {
+	w := performRequest(suite.router, "POST", "/api/v1/login", gin.H{"username": username, "password": password}, "")
+	var body struct {
+		Token string `json:"token"`
+	}
+	decodeJSON(suite.T(), w, &body)
+	return w.Code, body.Token
+}
+
+func (suite *UserTestSuite) TestCreatedUserCanLogIn() {
+	w := performRequest(suite.router, "POST", "/api/v1/users",
+		gin.H{"username": "clerk", "password": "s3cret-pass"}, suite.jwtToken)
+	require.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), "password_hash")
+	assert.NotContains(suite.T(), w.Body.String(), "s3cret-pass")
+
+	var stored models.User
+	require.NoError(suite.T(), suite.db.First(&stored, "username = ?", "clerk").Error)
+	assert.NotEqual(suite.T(), "s3cret-pass", stored.PasswordHash)
+
+	code, token := suite.login("clerk", "s3cret-pass")
+	assert.Equal(suite.T(), http.StatusOK, code)
+	assert.NotEmpty(suite.T(), token)
+
+	code, _ = suite.login("clerk", "wrong-pass")
+	assert.Equal(suite.T(), http.StatusUnauthorized, code)
+
+	code, _ = suite.login("nobody", "s3cret-pass")
+	assert.Equal(suite.T(), http.StatusUnauthorized, code)
+}
+
+func (suite *UserTestSuite) TestCreateUserValidation() {
+	w := performRequest(suite.router, "POST", "/api/v1/users",
+		gin.H{"username": "admin", "password": "another-pass"}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/users",
+		gin.H{"username": "shorty", "password": "short"}, suite.jwtToken)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/users",
+		gin.H{"username": "clerk", "password": "s3cret-pass"}, "")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *UserTestSuite) TestDisabledUserIsLockedOut() {
+	user := suite.createUser("temp", "temp-password", models.RoleAdmin)
+	token, err := middleware.GenerateJWT("temp", models.RoleAdmin)
+	require.NoError(suite.T(), err)
+
+	w := performRequest(suite.router, "POST", "/api/v1/users/"+user.ID+"/disable", nil, suite.jwtToken)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	code, _ := suite.login("temp", "temp-password")
+	assert.Equal(suite.T(), http.StatusForbidden, code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/users", nil, token)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/users/"+user.ID+"/enable", nil, suite.jwtToken)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/users", nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *UserTestSuite) TestChangeOwnPasswordRequiresCurrentPassword() {
+	user := suite.createUser("self", "old-password", models.RoleViewer)
+	token, err := middleware.GenerateJWT("self", models.RoleViewer)
+	require.NoError(suite.T(), err)
+
+	w := performRequest(suite.router, "PUT", "/api/v1/users/"+user.ID+"/password",
+		gin.H{"current_password": "wrong-password", "new_password": "new-password"}, token)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	w = performRequest(suite.router, "PUT", "/api/v1/users/"+user.ID+"/password",
+		gin.H{"current_password": "old-password", "new_password": "new-password"}, token)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	code, _ := suite.login("self", "old-password")
+	assert.Equal(suite.T(), http.StatusUnauthorized, code)
+	code, _ = suite.login("self", "new-password")
+	assert.Equal(suite.T(), http.StatusOK, code)
+}
+
+func (suite *UserTestSuite) TestTokensIssuedBeforePasswordChangeAreRejected() {
+	suite.createUser("rotated", "old-password", models.RoleAdmin)
+	token, err := middleware.GenerateJWT("rotated", models.RoleAdmin)
+	require.NoError(suite.T(), err)
+
+	suite.db.Model(&models.User{}).Where("username = ?", "rotated").
+		Update("password_changed_at", time.Now().Add(time.Minute))
+
+	w := performRequest(suite.router, "GET", "/api/v1/users", nil, token)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func TestUserTestSuite(t *testing.T) {
+	suite.Run(t, new(UserTestSuite))
+}